@@ -0,0 +1,63 @@
+// Package agent implements a long-lived, TUI-free holder for derived
+// age-plugin-bip39 private keys. A single `age-plugin-bip39 agent`
+// process listens on a Unix socket and keeps keys in mlocked memory,
+// keyed by identity fingerprint, so a batch of `age -d` invocations only
+// needs one interactive unlock. It complements (and works even without)
+// the keycache package's OS-keyring backends.
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SocketEnvVar overrides the agent socket path, mainly for testing.
+const SocketEnvVar = "AGE_PLUGIN_BIP39_AGENT_SOCK"
+
+// DefaultIdleTTL is how long an added key is kept if the caller doesn't
+// specify its own TTL.
+const DefaultIdleTTL = 1 * time.Hour
+
+// SocketPath returns the path the agent listens on / clients dial,
+// honoring SocketEnvVar and falling back to XDG_RUNTIME_DIR, then
+// os.TempDir() if neither is set.
+func SocketPath() string {
+	if p := os.Getenv(SocketEnvVar); p != "" {
+		return p
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "age-plugin-bip39.sock")
+}
+
+// request is the wire format for a single client->server call. The
+// socket speaks newline-delimited JSON, one request/response per line.
+type request struct {
+	Op          string `json:"op"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Key         []byte `json:"key,omitempty"`
+	TTLSeconds  int    `json:"ttl_seconds,omitempty"`
+}
+
+// response is the wire format for a single server->client reply.
+type response struct {
+	OK      bool        `json:"ok"`
+	Error   string      `json:"error,omitempty"`
+	Key     []byte      `json:"key,omitempty"`
+	Entries []EntryInfo `json:"entries,omitempty"`
+}
+
+// EntryInfo describes a held key without exposing its bytes, for `agent
+// list`.
+type EntryInfo struct {
+	Fingerprint string    `json:"fingerprint"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func errNotRunning(err error) error {
+	return fmt.Errorf("agent not reachable at %s: %w", SocketPath(), err)
+}
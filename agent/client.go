@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+)
+
+func call(req request) (response, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(), 2*time.Second)
+	if err != nil {
+		return response{}, errNotRunning(err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return response{}, fmt.Errorf("failed to send request to agent: %w", err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return response{}, fmt.Errorf("failed to read agent response: %w", err)
+	}
+	if !resp.OK && resp.Error != "" {
+		return resp, fmt.Errorf("%s", resp.Error)
+	}
+	return resp, nil
+}
+
+// Running reports whether an agent is listening at SocketPath().
+func Running() bool {
+	conn, err := net.DialTimeout("unix", SocketPath(), 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Get asks the running agent for the key held under fingerprint. The
+// second return value is false if no agent is running or it holds no
+// such key - both are routine "fall through to prompting" conditions,
+// not errors worth surfacing.
+func Get(fingerprint string) ([]byte, bool) {
+	resp, err := call(request{Op: "get", Fingerprint: fingerprint})
+	if err != nil || !resp.OK {
+		return nil, false
+	}
+	return resp.Key, true
+}
+
+// Add stores key under fingerprint in the running agent for ttl (or the
+// agent's default if ttl is 0). It is a best-effort convenience call:
+// callers that don't require an agent to be running should ignore its
+// error.
+func Add(fingerprint string, key []byte, ttl time.Duration) error {
+	_, err := call(request{
+		Op:          "add",
+		Fingerprint: fingerprint,
+		Key:         key,
+		TTLSeconds:  int(ttl / time.Second),
+	})
+	return err
+}
+
+// List returns every key currently held by the running agent.
+func List() ([]EntryInfo, error) {
+	resp, err := call(request{Op: "list"})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
+}
+
+// Forget removes fingerprint from the running agent, if held.
+func Forget(fingerprint string) error {
+	_, err := call(request{Op: "forget", Fingerprint: fingerprint})
+	return err
+}
+
+// Lock wipes every key the running agent holds and refuses further `add`
+// and `get` calls until the agent process is restarted.
+func Lock() error {
+	_, err := call(request{Op: "lock"})
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package agent
+
+// lockMemory is a no-op where we have no portable syscall to pin pages
+// (notably Windows, where the equivalent is VirtualLock over a
+// already-committed region - not worth the extra per-platform build file
+// for a best-effort protection this package treats as optional).
+func lockMemory(b []byte) error {
+	return nil
+}
+
+func unlockMemory(b []byte) {}
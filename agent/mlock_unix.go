@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package agent
+
+import "golang.org/x/sys/unix"
+
+// lockMemory pins b's pages in RAM so the derived key is never written to
+// swap. Best-effort: a failure here (e.g. hitting RLIMIT_MEMLOCK) is
+// surfaced to the caller, who decides whether to proceed anyway.
+func lockMemory(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return unix.Mlock(b)
+}
+
+// unlockMemory releases a prior lockMemory call. Called after the key has
+// already been zeroed, so failures here aren't security-relevant and are
+// ignored, matching the rest of the wipe() cleanup path.
+func unlockMemory(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	_ = unix.Munlock(b)
+}
@@ -0,0 +1,45 @@
+//go:build darwin
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkPeerCredential verifies the connecting process is owned by the
+// same user as the agent, via LOCAL_PEERCRED (macOS's equivalent of
+// Linux's SO_PEERCRED), before handling its request.
+func checkPeerCredential(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("failed to get raw socket: %w", err)
+	}
+	var uid uint32
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		xucred, e := unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+		if e != nil {
+			credErr = e
+			return
+		}
+		uid = xucred.Uid
+	})
+	if err != nil {
+		return fmt.Errorf("failed to inspect peer credentials: %w", err)
+	}
+	if credErr != nil {
+		return fmt.Errorf("LOCAL_PEERCRED failed: %w", credErr)
+	}
+	if int(uid) != os.Getuid() {
+		return fmt.Errorf("rejecting connection from uid %d", uid)
+	}
+	return nil
+}
+
+// peerCredentialCheckSupported is true: LOCAL_PEERCRED above does real
+// verification on this platform.
+func peerCredentialCheckSupported() bool { return true }
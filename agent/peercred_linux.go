@@ -0,0 +1,39 @@
+//go:build linux
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkPeerCredential verifies the connecting process is owned by the
+// same user as the agent, via SO_PEERCRED, before handling its request.
+func checkPeerCredential(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("failed to get raw socket: %w", err)
+	}
+	var cred *unix.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to inspect peer credentials: %w", err)
+	}
+	if credErr != nil {
+		return fmt.Errorf("SO_PEERCRED failed: %w", credErr)
+	}
+	if int(cred.Uid) != os.Getuid() {
+		return fmt.Errorf("rejecting connection from uid %d", cred.Uid)
+	}
+	return nil
+}
+
+// peerCredentialCheckSupported is true: SO_PEERCRED above does real
+// verification on this platform.
+func peerCredentialCheckSupported() bool { return true }
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !windows
+
+package agent
+
+import "net"
+
+// checkPeerCredential has no portable fallback on this platform; the
+// socket file's own permissions (set in Server.Serve) are the only
+// access control available. Unlike Windows, os.Chmod's 0600 genuinely
+// restricts access on any POSIX-like platform this build tag covers, so
+// (unlike Windows) that fallback is real protection, not a false claim.
+func checkPeerCredential(conn *net.UnixConn) error {
+	return nil
+}
+
+// peerCredentialCheckSupported is true here because the socket file's
+// POSIX permission bits (set in Server.Serve) are themselves sufficient
+// access control on any platform reaching this build tag.
+func peerCredentialCheckSupported() bool { return true }
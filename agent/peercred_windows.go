@@ -0,0 +1,23 @@
+//go:build windows
+
+package agent
+
+import "net"
+
+// checkPeerCredential is unreachable on Windows: peerCredentialCheckSupported
+// is false here, so Server.Serve refuses to start instead of ever calling
+// this. Recent Windows versions serve AF_UNIX sockets but expose no
+// LOCAL_PEERCRED/SO_PEERCRED equivalent through the standard library, and
+// os.Chmod does not enforce POSIX-style permission bits on Windows either
+// - there is no access control available through this socket-file-based
+// design on this platform, so we refuse to serve rather than claim a
+// protection that isn't real.
+func checkPeerCredential(conn *net.UnixConn) error {
+	return nil
+}
+
+// peerCredentialCheckSupported is false: see checkPeerCredential's comment.
+// A real fix needs a different transport (e.g. a named pipe with an
+// explicit ACL, as ssh-agent/gpg-agent use on Windows) rather than a Unix
+// socket plus a file-permission check that Windows doesn't honor.
+func peerCredentialCheckSupported() bool { return false }
@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// entry is one held key. Data lives in mlocked memory (see mlock_*.go) for
+// as long as the entry is alive, and is wiped on removal.
+type entry struct {
+	key       []byte
+	expiresAt time.Time
+}
+
+// Server holds derived keys in memory for the lifetime of the process and
+// serves them over a Unix socket. Zero value is not usable; use
+// NewServer.
+type Server struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	locked  bool
+}
+
+// NewServer returns an empty, unlocked Server.
+func NewServer() *Server {
+	return &Server{entries: make(map[string]*entry)}
+}
+
+// Serve listens on socketPath (removing a stale socket file first, as is
+// conventional for Unix-socket daemons) and blocks, handling connections
+// until the listener errors or the process is killed.
+func (s *Server) Serve(socketPath string) error {
+	if !peerCredentialCheckSupported() {
+		return fmt.Errorf("refusing to serve the agent socket: no way to verify connecting processes on this platform (see agent/peercred_windows.go)")
+	}
+
+	_ = os.Remove(socketPath)
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	defer l.Close()
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		return fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	go s.reapExpired()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return fmt.Errorf("accept failed: %w", err)
+		}
+		uc, ok := conn.(*net.UnixConn)
+		if ok {
+			if err := checkPeerCredential(uc); err != nil {
+				conn.Close()
+				continue
+			}
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) reapExpired() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		s.mu.Lock()
+		for fp, e := range s.entries {
+			if now.After(e.expiresAt) {
+				wipe(e.key)
+				delete(s.entries, fp)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+
+	var req request
+	if err := dec.Decode(&req); err != nil {
+		return
+	}
+	enc.Encode(s.handle(req))
+}
+
+func (s *Server) handle(req request) response {
+	switch req.Op {
+	case "add":
+		return s.doAdd(req)
+	case "get":
+		return s.doGet(req)
+	case "list":
+		return s.doList()
+	case "forget":
+		return s.doForget(req)
+	case "lock":
+		return s.doLock()
+	default:
+		return response{Error: fmt.Sprintf("unknown op %q", req.Op)}
+	}
+}
+
+func (s *Server) doAdd(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return response{Error: "agent is locked"}
+	}
+	ttl := DefaultIdleTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	key := make([]byte, len(req.Key))
+	copy(key, req.Key)
+	if err := lockMemory(key); err != nil {
+		return response{Error: fmt.Sprintf("mlock failed: %v", err)}
+	}
+	if old, ok := s.entries[req.Fingerprint]; ok {
+		wipe(old.key)
+	}
+	s.entries[req.Fingerprint] = &entry{key: key, expiresAt: time.Now().Add(ttl)}
+	return response{OK: true}
+}
+
+func (s *Server) doGet(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return response{Error: "agent is locked"}
+	}
+	e, ok := s.entries[req.Fingerprint]
+	if !ok || time.Now().After(e.expiresAt) {
+		return response{Error: "no key held for this identity"}
+	}
+	return response{OK: true, Key: e.key}
+}
+
+func (s *Server) doList() response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]EntryInfo, 0, len(s.entries))
+	for fp, e := range s.entries {
+		entries = append(entries, EntryInfo{Fingerprint: fp, ExpiresAt: e.expiresAt})
+	}
+	return response{OK: true, Entries: entries}
+}
+
+func (s *Server) doForget(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[req.Fingerprint]; ok {
+		wipe(e.key)
+		delete(s.entries, req.Fingerprint)
+	}
+	return response{OK: true}
+}
+
+func (s *Server) doLock() response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for fp, e := range s.entries {
+		wipe(e.key)
+		delete(s.entries, fp)
+	}
+	s.locked = true
+	return response{OK: true}
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	unlockMemory(b)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	bip39 "github.com/tyler-smith/go-bip39"
+
+	"github.com/pinpox/age-plugin-bip39/agent"
+)
+
+// identityFingerprint names an identity for `agent` bookkeeping: a short,
+// stable, non-reversible label derived from its public key, distinct from
+// the cacheSalt used to key the keycache package so the two caches can't
+// be confused for one another.
+func identityFingerprint(publicKey []byte) string {
+	sum := sha256.Sum256(append([]byte("age-plugin-bip39-agent-fingerprint"), publicKey...))
+	return hex.EncodeToString(sum[:8])
+}
+
+// runAgentCommand implements the `age-plugin-bip39 agent [add|list|forget|lock]`
+// group. With no subcommand it runs the agent server in the foreground.
+func runAgentCommand(args []string) error {
+	if len(args) == 0 {
+		return runAgentServe()
+	}
+	switch args[0] {
+	case "add":
+		return runAgentAdd(args[1:])
+	case "list":
+		return runAgentList()
+	case "forget":
+		return runAgentForget(args[1:])
+	case "lock":
+		return runAgentLock()
+	default:
+		return fmt.Errorf("usage: age-plugin-bip39 agent [add|list|forget <fingerprint>|lock]")
+	}
+}
+
+func runAgentServe() error {
+	socketPath := agent.SocketPath()
+	fmt.Fprintf(os.Stderr, "age-plugin-bip39 agent listening on %s\n", socketPath)
+	return agent.NewServer().Serve(socketPath)
+}
+
+// runAgentAdd reads an existing mnemonic (and optional passphrase) from
+// stdin, derives its identity the same way `-k` does, and loads the
+// resulting key into the running agent. It deliberately reads from stdin
+// rather than the `-k` word grid: that grid's verify step exists to catch
+// transcription mistakes while generating a *new* phrase, which doesn't
+// apply when feeding in a phrase the user already has.
+func runAgentAdd(args []string) error {
+	fs := flag.NewFlagSet("agent add", flag.ContinueOnError)
+	ttl := fs.Duration("ttl", agent.DefaultIdleTTL, "how long the agent should hold this key")
+	lang := fs.String("lang", defaultLanguage, "BIP39 wordlist language")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := setLanguage(*lang); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Enter your BIP39 seed phrase:")
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	if !scanner.Scan() {
+		return fmt.Errorf("no mnemonic provided on stdin")
+	}
+	mnemonic := strings.TrimSpace(scanner.Text())
+
+	fmt.Fprintln(os.Stderr, "Enter your BIP39 passphrase (leave empty if none):")
+	var passphrase string
+	if scanner.Scan() {
+		passphrase = strings.TrimSpace(scanner.Text())
+	}
+
+	if _, err := detectLanguage(mnemonic); err != nil {
+		return fmt.Errorf("could not determine mnemonic language: %w", err)
+	}
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return fmt.Errorf("invalid BIP39 mnemonic")
+	}
+
+	privKey, pubKey, err := deriveX25519FromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return fmt.Errorf("key derivation failed: %w", err)
+	}
+
+	fp := identityFingerprint(pubKey)
+	if err := agent.Add(fp, privKey, *ttl); err != nil {
+		return fmt.Errorf("failed to add key to agent: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Added identity %s to agent (ttl %s).\n", fp, ttl.String())
+	return nil
+}
+
+func runAgentList() error {
+	entries, err := agent.List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No identities held by agent.")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("%s\texpires %s\n", e.Fingerprint, e.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func runAgentForget(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: age-plugin-bip39 agent forget <fingerprint>")
+	}
+	if err := agent.Forget(args[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Forgot identity %s.\n", args[0])
+	return nil
+}
+
+func runAgentLock() error {
+	if err := agent.Lock(); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Agent locked.")
+	return nil
+}
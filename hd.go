@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"strconv"
+	"strings"
+
+	bip32 "github.com/tyler-smith/go-bip32"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/curve25519"
+)
+
+// hardenedOffset is added to a path component ending in "'" or "h", per
+// BIP32's hardened-derivation convention.
+const hardenedOffset = uint32(0x80000000)
+
+// parseHDPath parses a BIP32/BIP44-style path like "m/44'/0'/0'/0/0" into
+// its child-key indices.
+func parseHDPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with \"m\"", path)
+	}
+
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: bad component %q: %w", path, part, err)
+		}
+		index := uint32(n)
+		if hardened {
+			index += hardenedOffset
+		}
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// deriveX25519AtPath derives the X25519 keypair for one BIP32 child key
+// along path, starting from the BIP39 seed for mnemonic+passphrase. The
+// child's 32-byte BIP32 private key is hashed through SHA-512 and clamped
+// exactly like the non-HD path, but the two derivations diverge even at
+// path "m": bip32.NewMasterKey(seed).Key is the HMAC-SHA512 IL output
+// over seed, not seed itself, so sha512(IL)[:32] != sha512(seed)[:32].
+// Path "m" is NOT a drop-in substitute for deriveX25519FromMnemonic.
+func deriveX25519AtPath(mnemonic, passphrase, path string) (privateKey, publicKey []byte, err error) {
+	indices, err := parseHDPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive seed: %w", err)
+	}
+
+	key, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive master key: %w", err)
+	}
+	for _, index := range indices {
+		key, err = key.NewChildKey(index)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to derive child key at %q: %w", path, err)
+		}
+	}
+
+	h := sha512.Sum512(key.Key)
+	x25519Private := make([]byte, 32)
+	copy(x25519Private, h[:32])
+	x25519Private[0] &= 248
+	x25519Private[31] &= 127
+	x25519Private[31] |= 64
+
+	pubBytes, err := curve25519.X25519(x25519Private, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("X25519 scalar multiplication failed: %w", err)
+	}
+	return x25519Private, pubBytes, nil
+}
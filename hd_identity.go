@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/plugin"
+	"github.com/pinpox/age-plugin-bip39/agent"
+	"github.com/pinpox/age-plugin-bip39/keycache"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+// hdIdentityPayload builds the `kind || pubkey || path` identity payload
+// described by identityKindHD. The path is stored as its literal string
+// form (e.g. "m/44'/0'/0'/0/0") since it's public information - only the
+// mnemonic it's combined with is secret.
+func hdIdentityPayload(pubKey []byte, path string) []byte {
+	payload := make([]byte, 0, 1+len(pubKey)+len(path))
+	payload = append(payload, identityKindHD)
+	payload = append(payload, pubKey...)
+	payload = append(payload, path...)
+	return payload
+}
+
+func hdIdentityPublicKey(data []byte) ([]byte, error) {
+	if len(data) < 1+32+1 || data[0] != identityKindHD {
+		return nil, fmt.Errorf("invalid HD identity data length: %d", len(data))
+	}
+	return data[1:33], nil
+}
+
+// HDIdentity implements age.Identity for a BIP32/BIP44 child key: the
+// private key is re-derived from the user's mnemonic plus the stored
+// derivation path.
+type HDIdentity struct {
+	plugin    *plugin.Plugin
+	publicKey []byte
+	path      string
+}
+
+func parseHDIdentity(p *plugin.Plugin, data []byte) (age.Identity, error) {
+	if len(data) < 1+32+1 || data[0] != identityKindHD {
+		return nil, fmt.Errorf("invalid HD identity data length: %d", len(data))
+	}
+	path := string(data[33:])
+	if _, err := parseHDPath(path); err != nil {
+		return nil, fmt.Errorf("invalid HD identity: %w", err)
+	}
+	return &HDIdentity{plugin: p, publicKey: data[1:33], path: path}, nil
+}
+
+func (hi *HDIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	hasX25519 := false
+	for _, s := range stanzas {
+		if s.Type == "X25519" {
+			hasX25519 = true
+			break
+		}
+	}
+	if !hasX25519 {
+		return nil, age.ErrIncorrectIdentity
+	}
+
+	cacheKeyName := fmt.Sprintf("age-plugin-bip39:%x", cacheSalt(hi.publicKey))
+	privKey := keycache.Get(cacheKeyName)
+	if privKey == nil {
+		privKey, _ = agent.Get(identityFingerprint(hi.publicKey))
+	}
+
+	if privKey == nil {
+		mnemonic, err := hi.plugin.RequestValue(fmt.Sprintf("Enter your BIP39 seed phrase (derivation path %s)", hi.path), true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request seed phrase: %w", err)
+		}
+
+		mnemonic = strings.TrimSpace(mnemonic)
+		if _, err := detectLanguage(mnemonic); err != nil {
+			return nil, fmt.Errorf("could not determine mnemonic language: %w", err)
+		}
+		if !bip39.IsMnemonicValid(mnemonic) {
+			return nil, fmt.Errorf("invalid BIP39 mnemonic")
+		}
+
+		passphrase, err := hi.plugin.RequestValue("Enter your BIP39 passphrase (leave empty if none)", true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request passphrase: %w", err)
+		}
+
+		derivedPriv, derivedPub, err := deriveX25519AtPath(mnemonic, passphrase, hi.path)
+		if err != nil {
+			return nil, fmt.Errorf("key derivation failed: %w", err)
+		}
+		if !bytesEqual(derivedPub, hi.publicKey) {
+			return nil, fmt.Errorf("seed phrase does not match this identity at path %s", hi.path)
+		}
+
+		privKey = derivedPriv
+		keycache.Put(cacheKeyName, privKey)
+		_ = agent.Add(identityFingerprint(hi.publicKey), privKey, 0)
+	}
+
+	for _, stanza := range stanzas {
+		if stanza.Type != "X25519" {
+			continue
+		}
+		fileKey, err := unwrapX25519(privKey, hi.publicKey, stanza)
+		if err != nil {
+			continue
+		}
+		return fileKey, nil
+	}
+	return nil, age.ErrIncorrectIdentity
+}
@@ -0,0 +1,174 @@
+package keycache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// diskBackend seals each cached key into an AES-GCM blob under cacheDir(),
+// keyed by a wrap key obtained from wrapKey. The plaintext carries an
+// absolute expiry timestamp (not a TTL-since-mtime), so the file's own
+// mtime can't be touched to forge extended validity, and the expiry itself
+// can't be altered without failing authentication. Get compares that
+// expiry against the current wall-clock time and deletes an entry it finds
+// expired; an entry not read since it expired still looks fresh if the
+// system clock is set back to before its expiry.
+type diskBackend struct {
+	wrapKey func(dir string) ([]byte, error)
+}
+
+func cacheDir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "age-plugin-bip39"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "age-plugin-bip39"), nil
+}
+
+func entryPath(dir, name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".key")
+}
+
+func localWrapKeyPath(dir string) string {
+	return filepath.Join(dir, "wrapkey")
+}
+
+// loadOrCreateLocalWrapKey returns the AES-256 key used to seal cache
+// entries, generating and persisting one (mode 0600) on first use. It is
+// only reached as loadOrCreateKeyringWrapKey's fallback (keyring.go) for
+// when no OS secret store is reachable - there's no standalone, directly
+// selectable "local file" backend.
+func loadOrCreateLocalWrapKey(dir string) ([]byte, error) {
+	path := localWrapKeyPath(dir)
+	if b, err := os.ReadFile(path); err == nil && len(b) == 32 {
+		return b, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (b diskBackend) Get(name string) ([]byte, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	path := entryPath(dir, name)
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+
+	wrapKey, err := b.wrapKey(dir)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aesGCMOpen(wrapKey, sealed)
+	if err != nil {
+		return nil, nil
+	}
+	if len(plaintext) < 8 {
+		return nil, nil
+	}
+	expiresAt := int64(binary.BigEndian.Uint64(plaintext[:8]))
+	key := plaintext[8:]
+
+	if time.Now().Unix() > expiresAt {
+		_ = os.Remove(path)
+		return nil, nil
+	}
+	return key, nil
+}
+
+func (b diskBackend) Put(name string, key []byte, ttl time.Duration) error {
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	wrapKey, err := b.wrapKey(dir)
+	if err != nil {
+		return err
+	}
+
+	plaintext := make([]byte, 8+len(key))
+	binary.BigEndian.PutUint64(plaintext[:8], uint64(time.Now().Add(ttl).Unix()))
+	copy(plaintext[8:], key)
+
+	sealed, err := aesGCMSeal(wrapKey, plaintext)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(entryPath(dir, name), sealed, 0600)
+}
+
+func (b diskBackend) Purge(name string) error {
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return os.RemoveAll(dir)
+	}
+	err = os.Remove(entryPath(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func aesGCMSeal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func aesGCMOpen(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("keycache: sealed blob too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,108 @@
+// Package keycache caches derived age identity keys across invocations so
+// that decrypting many files in a row doesn't re-prompt for the BIP39 seed
+// phrase every time. The default backend seals entries in an AES-GCM file
+// under $XDG_RUNTIME_DIR, wrapped by a key held in the OS secret store
+// (Secret Service, Keychain, Credential Manager, via go-keyring); an
+// in-process-memory and a no-op backend are also selectable, via
+// AGE_PLUGIN_BIP39_CACHE_BACKEND.
+package keycache
+
+import (
+	"os"
+	"time"
+)
+
+// Backend is a pluggable storage mechanism for cached keys.
+type Backend interface {
+	// Get returns the cached key for name, or nil if there is no
+	// (unexpired) entry.
+	Get(name string) ([]byte, error)
+	// Put stores key under name with the given time-to-live.
+	Put(name string, key []byte, ttl time.Duration) error
+	// Purge removes any cached entry for name. If name is empty, all
+	// entries managed by this backend are removed.
+	Purge(name string) error
+}
+
+// EnvVar is the environment variable used to select and configure the
+// backend. Recognized values are "none" (or "off"), "memory" and
+// "keyring". Anything else (including unset) selects "keyring", the
+// platform default.
+const EnvVar = "AGE_PLUGIN_BIP39_CACHE_BACKEND"
+
+// TTLEnvVar holds a duration (e.g. "10m") controlling how long a freshly
+// cached key remains valid. A value of "0" disables caching entirely.
+const TTLEnvVar = "AGE_PLUGIN_BIP39_CACHE_TTL"
+
+// defaultTTL is used when TTLEnvVar is unset.
+const defaultTTL = 10 * time.Minute
+
+// noop is a Backend that never stores anything, used when caching is off.
+type noop struct{}
+
+func (noop) Get(string) ([]byte, error)              { return nil, nil }
+func (noop) Put(string, []byte, time.Duration) error { return nil }
+func (noop) Purge(string) error                      { return nil }
+
+// Select returns the Backend named by EnvVar, falling back to the
+// keyring backend when the variable is unset or unrecognized.
+func Select() Backend {
+	switch os.Getenv(EnvVar) {
+	case "off", "none":
+		return noop{}
+	case "memory":
+		return newMemoryBackend()
+	case "keyring":
+		return newKeyringBackend()
+	default:
+		return newKeyringBackend()
+	}
+}
+
+// TTL returns the configured cache TTL, or 0 if caching is disabled.
+func TTL() time.Duration {
+	switch os.Getenv(EnvVar) {
+	case "off", "none":
+		return 0
+	}
+	v := os.Getenv(TTLEnvVar)
+	if v == "" {
+		return defaultTTL
+	}
+	if v == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return defaultTTL
+	}
+	return d
+}
+
+// Get looks up name in the selected backend.
+func Get(name string) []byte {
+	if TTL() == 0 {
+		return nil
+	}
+	key, err := Select().Get(name)
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+// Put stores key under name in the selected backend using the configured
+// TTL. It is a no-op if caching is disabled.
+func Put(name string, key []byte) {
+	ttl := TTL()
+	if ttl == 0 {
+		return
+	}
+	_ = Select().Put(name, key, ttl)
+}
+
+// Purge removes the cached entry for name from the selected backend. If
+// name is empty, every entry the backend manages is removed.
+func Purge(name string) error {
+	return Select().Purge(name)
+}
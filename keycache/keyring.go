@@ -0,0 +1,55 @@
+package keycache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringService namespaces this plugin's entries in the OS secret store.
+const keyringService = "age-plugin-bip39"
+
+// newKeyringBackend returns the default, OS-secret-store-backed backend:
+// the actual cache entries are diskBackend's usual sealed files, but the
+// AES wrap key that protects them lives in the platform secret store
+// (Secret Service on Linux, Keychain on macOS, Credential Manager on
+// Windows, all via go-keyring) instead of next to the files themselves.
+// That way a reboot or an explicit keyring purge invalidates every cached
+// key at once, without the plugin needing its own per-OS keyring code.
+func newKeyringBackend() Backend { return diskBackend{wrapKey: loadOrCreateKeyringWrapKey} }
+
+// keyringWrapKeyAccount identifies the wrap key within keyringService,
+// scoped per cache directory so a per-user XDG_RUNTIME_DIR can't collide
+// with another user's entry in a shared keyring.
+func keyringWrapKeyAccount(dir string) string {
+	return "wrapkey:" + dir
+}
+
+// loadOrCreateKeyringWrapKey falls back to the plain local-file wrap key
+// (loadOrCreateLocalWrapKey) when the OS has no secret store go-keyring
+// can reach - e.g. a headless Linux box with no Secret Service running -
+// rather than making every cached key unreachable on those systems.
+func loadOrCreateKeyringWrapKey(dir string) ([]byte, error) {
+	account := keyringWrapKeyAccount(dir)
+
+	if stored, err := keyring.Get(keyringService, account); err == nil {
+		key, err := hex.DecodeString(stored)
+		if err == nil && len(key) == 32 {
+			return key, nil
+		}
+	} else if !errors.Is(err, keyring.ErrNotFound) {
+		return loadOrCreateLocalWrapKey(dir)
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := keyring.Set(keyringService, account, hex.EncodeToString(key)); err != nil {
+		return loadOrCreateLocalWrapKey(dir)
+	}
+	return key, nil
+}
@@ -0,0 +1,60 @@
+package keycache
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryBackend caches keys in process memory only. Since this plugin is
+// re-exec'd by age for every decryption, it has no effect across
+// invocations — it exists mainly for tests and for callers embedding the
+// plugin as a library within a single long-lived process. (The agent
+// package keeps its own key store and does not use this backend.)
+type memoryBackend struct {
+	mu      *sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	key       []byte
+	expiresAt time.Time
+}
+
+var (
+	sharedMemoryMu      sync.Mutex
+	sharedMemoryEntries = map[string]memoryEntry{}
+)
+
+func newMemoryBackend() Backend {
+	return memoryBackend{mu: &sharedMemoryMu, entries: sharedMemoryEntries}
+}
+
+func (b memoryBackend) Get(name string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entries[name]
+	if !ok || time.Now().After(e.expiresAt) {
+		delete(b.entries, name)
+		return nil, nil
+	}
+	return e.key, nil
+}
+
+func (b memoryBackend) Put(name string, key []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[name] = memoryEntry{key: key, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (b memoryBackend) Purge(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if name == "" {
+		b.entries = map[string]memoryEntry{}
+		sharedMemoryEntries = b.entries
+		return nil
+	}
+	delete(b.entries, name)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+// languages maps a --lang value to the wordlist it selects. go-bip39
+// ships these nine official BIP39 wordlists; we just need to pick one
+// with SetWordList.
+var languages = map[string][]string{
+	"english":             wordlists.English,
+	"japanese":            wordlists.Japanese,
+	"spanish":             wordlists.Spanish,
+	"french":              wordlists.French,
+	"italian":             wordlists.Italian,
+	"korean":              wordlists.Korean,
+	"czech":               wordlists.Czech,
+	"chinese_simplified":  wordlists.ChineseSimplified,
+	"chinese_traditional": wordlists.ChineseTraditional,
+}
+
+// defaultLanguage is used when --lang is unset.
+const defaultLanguage = "english"
+
+// supportedLanguageNames lists valid --lang values, for error messages.
+func supportedLanguageNames() []string {
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	return names
+}
+
+// setLanguage points the bip39 package's active wordlist at name.
+func setLanguage(name string) error {
+	list, ok := languages[name]
+	if !ok {
+		return fmt.Errorf("unsupported language %q (supported: %s)", name, strings.Join(supportedLanguageNames(), ", "))
+	}
+	bip39.SetWordList(list)
+	return nil
+}
+
+// detectLanguage finds the wordlist that validates mnemonic in full
+// (checksum included) and switches the active bip39 wordlist to it,
+// returning the language name. Used on decrypt, where the mnemonic's
+// language isn't known ahead of time.
+//
+// It checks the *whole* mnemonic's checksum against each candidate
+// wordlist rather than just the first word's membership: several words
+// (e.g. "romance", "valve") are valid in more than one official BIP39
+// wordlist, so a first-word-only check can pick the wrong wordlist and
+// leave IsMnemonicValid's result depending on which list happened to be
+// tried first. Candidates are tried in a fixed, sorted order so the
+// result doesn't depend on Go's randomized map iteration.
+func detectLanguage(mnemonic string) (string, error) {
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		bip39.SetWordList(languages[name])
+		if bip39.IsMnemonicValid(mnemonic) {
+			return name, nil
+		}
+	}
+	_ = setLanguage(defaultLanguage)
+	return "", fmt.Errorf("mnemonic is not valid in any supported BIP39 wordlist")
+}
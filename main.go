@@ -11,12 +11,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"filippo.io/age"
 	"filippo.io/age/plugin"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/pinpox/age-plugin-bip39/agent"
+	"github.com/pinpox/age-plugin-bip39/keycache"
 	bip39 "github.com/tyler-smith/go-bip39"
 	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/curve25519"
@@ -29,6 +32,30 @@ const x25519Label = "age-encryption.org/v1/X25519"
 var b64 = base64.RawStdEncoding.Strict()
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		if err := runCacheCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "slip39-generate" {
+		if err := runSlip39GenerateCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "agent" {
+		if err := runAgentCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	p, err := plugin.New("bip39")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -36,12 +63,42 @@ func main() {
 	}
 
 	var keygen bool
+	var words int
+	var lang string
+	var hdPath string
+	var splitSpec string
 	p.RegisterFlags(nil)
 	flag.BoolVar(&keygen, "k", false, "generate a new identity from a BIP39 seed phrase")
+	flag.IntVar(&words, "words", 24, "mnemonic length for -k: 12, 15, 18, 21, or 24")
+	flag.StringVar(&lang, "lang", defaultLanguage, "BIP39 wordlist language for -k (english, japanese, spanish, french, italian, korean, czech, chinese_simplified, chinese_traditional)")
+	flag.StringVar(&hdPath, "d", "", "BIP32/BIP44 derivation path for -k, e.g. m/44'/0'/0'/0/0 (default: none, plain seed-derived identity)")
+	flag.StringVar(&splitSpec, "s", "", "split -k's identity into SLIP-39 shares instead, as T,N (threshold,shares)")
 	flag.Parse()
 
+	if keygen && splitSpec != "" {
+		threshold, shares, err := parseSplitSpec(splitSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		passphrase, err := runPassphrasePrompt()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := runSlip39Generate(threshold, shares, passphrase); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if keygen {
-		if err := runKeygen(); err != nil {
+		if err := setLanguage(lang); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := runKeygen(words, hdPath); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -50,40 +107,103 @@ func main() {
 
 	if !hasFlag("age-plugin") {
 		fmt.Fprintf(os.Stderr, "Usage:\n")
-		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 -k    Generate an identity from a BIP39 seed phrase\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 -k             Generate an identity from a BIP39 seed phrase\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 -k -words N    Use a %d/15/18/21/24-word mnemonic (default 24)\n", supportedWordCounts[0])
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 -k -d PATH     Derive a BIP32/BIP44 subkey, e.g. m/44'/0'/0'/0/0\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 -k -s T,N      Split the identity into N SLIP-39-style shares (any T reconstruct it)\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 cache purge    Remove all cached derived keys\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 slip39-generate -t T -n N\n")
+		fmt.Fprintf(os.Stderr, "                                   Generate an identity split into N SLIP-39-style shares (any T reconstruct it)\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 slip39-generate -g T,N -m t1,n1 -m t2,n2 ...\n")
+		fmt.Fprintf(os.Stderr, "                                   Grouped variant: split into N groups (any T reconstruct it), each\n")
+		fmt.Fprintf(os.Stderr, "                                   group itself split per its own -m ti,ni (NOT SLIP-39 wire-compatible)\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 agent          Run a long-lived agent so decryption doesn't re-prompt\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 agent add      Load an identity's key into the running agent\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 agent list     List identities held by the agent\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 agent forget <fingerprint>\n")
+		fmt.Fprintf(os.Stderr, "                                   Remove one identity from the agent\n")
+		fmt.Fprintf(os.Stderr, "  age-plugin-bip39 agent lock     Wipe all keys the agent holds\n")
 		fmt.Fprintf(os.Stderr, "\nThis plugin is invoked automatically by age during decryption.\n")
 		fmt.Fprintf(os.Stderr, "See https://github.com/pinpox/age-plugin-bip39 for details.\n")
 		fmt.Fprintf(os.Stderr, "\nEnvironment:\n")
-		fmt.Fprintf(os.Stderr, "  AGE_PLUGIN_BIP39_CACHE  Cache TTL for derived keys (default: 10m, 0 to disable)\n")
+		fmt.Fprintf(os.Stderr, "  %s  Cache TTL for derived keys (default: 10m, 0 to disable)\n", keycache.TTLEnvVar)
+		fmt.Fprintf(os.Stderr, "  %s  Cache backend: none|memory|keyring (default: keyring)\n", keycache.EnvVar)
 		os.Exit(0)
 	}
 
 	p.HandleIdentity(func(data []byte) (age.Identity, error) {
-		if len(data) != 32 {
-			return nil, fmt.Errorf("invalid identity data length: %d", len(data))
+		if len(data) == 32 {
+			return &Bip39Identity{plugin: p, publicKey: data}, nil
+		}
+		if len(data) > 0 && data[0] == identityKindSlip39 {
+			return parseSlip39Identity(p, data)
+		}
+		if len(data) > 0 && data[0] == identityKindHD {
+			return parseHDIdentity(p, data)
+		}
+		if len(data) > 0 && data[0] == identityKindSlip39Groups {
+			return parseSlip39GroupsIdentity(p, data)
 		}
-		return &Bip39Identity{plugin: p, publicKey: data}, nil
+		return nil, fmt.Errorf("invalid identity data length: %d", len(data))
 	})
 
 	p.HandleIdentityAsRecipient(func(data []byte) (age.Recipient, error) {
-		if len(data) != 32 {
-			return nil, fmt.Errorf("invalid identity data length: %d", len(data))
+		pub, err := identityPublicKey(data)
+		if err != nil {
+			return nil, err
 		}
-		return &Bip39Recipient{publicKey: data}, nil
+		return &Bip39Recipient{publicKey: pub}, nil
 	})
 
 	os.Exit(p.Main())
 }
 
-func runKeygen() error {
+// parseSplitSpec parses -s's "T,N" argument into a threshold and share count.
+func parseSplitSpec(spec string) (threshold, shares int, err error) {
+	t, n, ok := strings.Cut(spec, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid -s value %q: expected T,N (e.g. 3,5)", spec)
+	}
+	threshold, err = strconv.Atoi(strings.TrimSpace(t))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid -s threshold %q: %w", t, err)
+	}
+	shares, err = strconv.Atoi(strings.TrimSpace(n))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid -s share count %q: %w", n, err)
+	}
+	return threshold, shares, nil
+}
+
+// runCacheCommand implements the `age-plugin-bip39 cache <subcommand>` group.
+func runCacheCommand(args []string) error {
+	if len(args) != 1 || args[0] != "purge" {
+		return fmt.Errorf("usage: age-plugin-bip39 cache purge")
+	}
+	if err := keycache.Purge(""); err != nil {
+		return fmt.Errorf("failed to purge cache: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Cache purged.")
+	return nil
+}
+
+func runKeygen(wordCount int, hdPath string) error {
+	if _, ok := wordCountToEntropyBits[wordCount]; !ok {
+		return fmt.Errorf("invalid -words value %d: must be one of %v", wordCount, supportedWordCounts)
+	}
+	if hdPath != "" {
+		if _, err := parseHDPath(hdPath); err != nil {
+			return err
+		}
+	}
 	fd := int(os.Stdin.Fd())
 	if !term.IsTerminal(fd) {
-		return runKeygenNonInteractive()
+		return runKeygenNonInteractive(hdPath)
 	}
-	return runKeygenInteractive()
+	return runKeygenInteractive(wordCount, hdPath)
 }
 
-func runKeygenNonInteractive() error {
+func runKeygenNonInteractive(hdPath string) error {
 	b, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		return fmt.Errorf("failed to read seed phrase: %w", err)
@@ -92,14 +212,17 @@ func runKeygenNonInteractive() error {
 	if mnemonic == "" {
 		return fmt.Errorf("no mnemonic provided on stdin")
 	}
+	if _, err := detectLanguage(mnemonic); err != nil {
+		return fmt.Errorf("could not determine mnemonic language: %w", err)
+	}
 	if !bip39.IsMnemonicValid(mnemonic) {
 		return fmt.Errorf("invalid BIP39 mnemonic")
 	}
-	return outputIdentity(mnemonic)
+	return outputIdentity(mnemonic, "", hdPath)
 }
 
-func runKeygenInteractive() error {
-	entropy, err := bip39.NewEntropy(256)
+func runKeygenInteractive(wordCount int, hdPath string) error {
+	entropy, err := bip39.NewEntropy(wordCountToEntropyBits[wordCount])
 	if err != nil {
 		return fmt.Errorf("failed to generate entropy: %w", err)
 	}
@@ -114,11 +237,26 @@ func runKeygenInteractive() error {
 		return err
 	}
 
-	return outputIdentity(mnemonic)
+	passphrase, err := runPassphrasePrompt()
+	if err != nil {
+		return err
+	}
+
+	return outputIdentity(mnemonic, passphrase, hdPath)
 }
 
-func outputIdentity(mnemonic string) error {
-	privKey, pubKey, err := deriveX25519FromMnemonic(mnemonic)
+// outputIdentity derives and prints the identity/recipient pair for
+// mnemonic+passphrase. When hdPath is non-empty, the identity is a BIP32
+// child key at that path (payload: kind byte || pubkey || path) instead
+// of the plain seed-derived key (payload: bare pubkey).
+func outputIdentity(mnemonic, passphrase, hdPath string) error {
+	var privKey, pubKey []byte
+	var err error
+	if hdPath != "" {
+		privKey, pubKey, err = deriveX25519AtPath(mnemonic, passphrase, hdPath)
+	} else {
+		privKey, pubKey, err = deriveX25519FromMnemonic(mnemonic, passphrase)
+	}
 	if err != nil {
 		return fmt.Errorf("key derivation failed: %w", err)
 	}
@@ -134,7 +272,11 @@ func outputIdentity(mnemonic string) error {
 		return fmt.Errorf("failed to encode recipient: %w", err)
 	}
 
-	identity := plugin.EncodeIdentity("bip39", pubKey)
+	payload := pubKey
+	if hdPath != "" {
+		payload = hdIdentityPayload(pubKey, hdPath)
+	}
+	identity := plugin.EncodeIdentity("bip39", payload)
 	if identity == "" {
 		return fmt.Errorf("failed to encode identity")
 	}
@@ -147,9 +289,11 @@ func outputIdentity(mnemonic string) error {
 			BorderForeground(lipgloss.Color("63")).
 			Padding(0, 1)
 		label := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
-		fmt.Fprintf(os.Stderr, "\n%s\n\n", box.Render(
-			label.Render("Public Key")+"  "+recipient,
-		))
+		summary := label.Render("Public Key") + "  " + recipient
+		if hdPath != "" {
+			summary += "\n" + label.Render("Derivation Path") + "  " + hdPath
+		}
+		fmt.Fprintf(os.Stderr, "\n%s\n\n", box.Render(summary))
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
@@ -160,21 +304,51 @@ func outputIdentity(mnemonic string) error {
 }
 
 // deriveX25519FromMnemonic derives an X25519 keypair from a BIP39 mnemonic
-// using melt's mechanism: entropy = Ed25519 seed, then SHA-512(seed)[:32]
-// gives the X25519 private key (same as ssh-to-age).
-func deriveX25519FromMnemonic(mnemonic string) (privateKey, publicKey []byte, err error) {
+// and optional passphrase. It follows the standard BIP39 derivation
+// (PBKDF2-HMAC-SHA512 over the mnemonic, salted with "mnemonic"+passphrase)
+// rather than operating on the raw entropy, so a passphrase of "" still
+// yields the correct standard seed. SHA-512(seed)[:32] then gives the
+// X25519 private key (same approach as ssh-to-age).
+func deriveX25519FromMnemonic(mnemonic, passphrase string) (privateKey, publicKey []byte, err error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive seed: %w", err)
+	}
+
+	h := sha512.Sum512(seed)
+	x25519Private := make([]byte, 32)
+	copy(x25519Private, h[:32])
+
+	// Clamp the scalar (standard X25519 practice, matches Ed25519 key expansion)
+	x25519Private[0] &= 248
+	x25519Private[31] &= 127
+	x25519Private[31] |= 64
+
+	pubBytes, err := curve25519.X25519(x25519Private, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("X25519 scalar multiplication failed: %w", err)
+	}
+
+	return x25519Private, pubBytes, nil
+}
+
+// deriveX25519FromMnemonicLegacy reproduces the pre-chunk0-3 derivation:
+// entropy = EntropyFromMnemonic(mnemonic), then SHA-512(entropy)[:32]
+// gives the X25519 private key, with no passphrase involved at all. It
+// exists solely so Bip39Identity.Unwrap can still recover identities
+// generated before seed+passphrase derivation became the default - the
+// plain 32-byte identity payload has no flag byte to record which scheme
+// produced it, so the only way to tell is to try both and see which
+// pubkey matches.
+func deriveX25519FromMnemonicLegacy(mnemonic string) (privateKey, publicKey []byte, err error) {
 	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to extract entropy: %w", err)
 	}
 
-	// Ed25519 seed = entropy bytes
-	// X25519 private key = SHA-512(Ed25519 seed)[:32] (matches ssh-to-age)
 	h := sha512.Sum512(entropy)
 	x25519Private := make([]byte, 32)
 	copy(x25519Private, h[:32])
-
-	// Clamp the scalar (standard X25519 practice, matches Ed25519 key expansion)
 	x25519Private[0] &= 248
 	x25519Private[31] &= 127
 	x25519Private[31] |= 64
@@ -187,6 +361,35 @@ func deriveX25519FromMnemonic(mnemonic string) (privateKey, publicKey []byte, er
 	return x25519Private, pubBytes, nil
 }
 
+// Identity payload formats. A plain 32-byte payload is the original
+// bip39 identity (raw X25519 public key, no type byte, kept for backward
+// compatibility). Anything longer starts with a kind byte identifying a
+// newer, structured payload.
+const (
+	identityKindSlip39       = 0x01
+	identityKindHD           = 0x02
+	identityKindSlip39Groups = 0x03
+)
+
+// identityPublicKey extracts the X25519 public key from any supported
+// identity payload format, regardless of how the private key underneath
+// it is derived.
+func identityPublicKey(data []byte) ([]byte, error) {
+	if len(data) == 32 {
+		return data, nil
+	}
+	if len(data) > 0 && data[0] == identityKindSlip39 {
+		return slip39IdentityPublicKey(data)
+	}
+	if len(data) > 0 && data[0] == identityKindHD {
+		return hdIdentityPublicKey(data)
+	}
+	if len(data) > 0 && data[0] == identityKindSlip39Groups {
+		return slip39GroupsIdentityPublicKey(data)
+	}
+	return nil, fmt.Errorf("invalid identity data length: %d", len(data))
+}
+
 // Bip39Identity implements age.Identity for seed-phrase-derived keys.
 // The identity file stores only the public key; the private key is
 // derived on-demand from the user's seed phrase.
@@ -207,8 +410,20 @@ func (si *Bip39Identity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
 		return nil, age.ErrIncorrectIdentity
 	}
 
-	cacheKeyName := fmt.Sprintf("age-plugin-bip39:%x", si.publicKey)
-	privKey := getCachedKey(cacheKeyName)
+	// Cache lookups happen before we know the mnemonic or passphrase, so we
+	// can't key on a hash of them directly without defeating the point of
+	// caching (skipping the prompt on a hit). Instead we key on a salted
+	// hash of the identity's public key, which is itself a function of
+	// both mnemonic and passphrase, so distinct (mnemonic, passphrase)
+	// pairs still land in distinct cache entries.
+	cacheKeyName := fmt.Sprintf("age-plugin-bip39:%x", cacheSalt(si.publicKey))
+	privKey := keycache.Get(cacheKeyName)
+	if privKey == nil {
+		// The agent is a separate, TUI-free tier: checked in addition to
+		// keycache so a single `agent add` can cover a batch of `age -d`
+		// invocations even when no keyring backend is available.
+		privKey, _ = agent.Get(identityFingerprint(si.publicKey))
+	}
 
 	if privKey == nil {
 		mnemonic, err := si.plugin.RequestValue("Enter your BIP39 seed phrase", true)
@@ -217,21 +432,39 @@ func (si *Bip39Identity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
 		}
 
 		mnemonic = strings.TrimSpace(mnemonic)
+		if _, err := detectLanguage(mnemonic); err != nil {
+			return nil, fmt.Errorf("could not determine mnemonic language: %w", err)
+		}
 		if !bip39.IsMnemonicValid(mnemonic) {
 			return nil, fmt.Errorf("invalid BIP39 mnemonic")
 		}
 
-		derivedPriv, derivedPub, err := deriveX25519FromMnemonic(mnemonic)
+		passphrase, err := si.plugin.RequestValue("Enter your BIP39 passphrase (leave empty if none)", true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request passphrase: %w", err)
+		}
+
+		derivedPriv, derivedPub, err := deriveX25519FromMnemonic(mnemonic, passphrase)
 		if err != nil {
 			return nil, fmt.Errorf("key derivation failed: %w", err)
 		}
 
 		if !bytesEqual(derivedPub, si.publicKey) {
-			return nil, fmt.Errorf("seed phrase does not match this identity")
+			// Fall back to the pre-chunk0-3 entropy-based derivation: the
+			// plain 32-byte payload has no flag byte recording which
+			// scheme produced it, so an identity generated by an older
+			// binary would otherwise become unrecoverable from its seed
+			// phrase the moment the default derivation changed under it.
+			legacyPriv, legacyPub, legacyErr := deriveX25519FromMnemonicLegacy(mnemonic)
+			if legacyErr != nil || !bytesEqual(legacyPub, si.publicKey) {
+				return nil, fmt.Errorf("seed phrase does not match this identity")
+			}
+			derivedPriv = legacyPriv
 		}
 
 		privKey = derivedPriv
-		cacheKey(cacheKeyName, privKey)
+		keycache.Put(cacheKeyName, privKey)
+		_ = agent.Add(identityFingerprint(si.publicKey), privKey, 0)
 	}
 
 	for _, stanza := range stanzas {
@@ -339,6 +572,14 @@ func aeadDecrypt(key, ciphertext []byte) ([]byte, error) {
 	return aead.Open(nil, nonce, ciphertext, nil)
 }
 
+// cacheSalt derives the cache key material for an identity's public key,
+// namespaced so it can't be confused with any other use of SHA-256 over
+// the same bytes.
+func cacheSalt(publicKey []byte) []byte {
+	h := sha256.Sum256(append([]byte("age-plugin-bip39/cache/v1:"), publicKey...))
+	return h[:]
+}
+
 func hasFlag(name string) bool {
 	found := false
 	flag.Visit(func(f *flag.Flag) {
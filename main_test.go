@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+// TestBIP39SeedVectors checks our derivation pipeline's seed step against
+// the reference test vectors published in the BIP39 spec (trezor's
+// vectors.json), to guard interoperability with wallet tools that derive
+// from the same mnemonic+passphrase pair.
+//
+// Plain identities are a bare 32-byte pubkey with no flag byte to record
+// which derivation scheme produced them, so there was no way to add an
+// opt-in selector without breaking the on-disk format further. Instead,
+// Bip39Identity.Unwrap (main.go) falls back to the pre-chunk0-3
+// entropy-based derivation (deriveX25519FromMnemonicLegacy) whenever the
+// new seed+passphrase derivation's pubkey doesn't match - see
+// TestDeriveX25519LegacyFallback below, which exercises that path.
+func TestBIP39SeedVectors(t *testing.T) {
+	vectors := []struct {
+		mnemonic   string
+		passphrase string
+		seedHex    string
+	}{
+		{
+			mnemonic:   "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			passphrase: "TREZOR",
+			seedHex:    "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			mnemonic:   "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			passphrase: "TREZOR",
+			seedHex:    "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+	}
+
+	if err := setLanguage(defaultLanguage); err != nil {
+		t.Fatalf("setLanguage: %v", err)
+	}
+
+	for _, v := range vectors {
+		seed, err := bip39.NewSeedWithErrorChecking(v.mnemonic, v.passphrase)
+		if err != nil {
+			t.Fatalf("NewSeedWithErrorChecking(%q, %q): %v", v.mnemonic, v.passphrase, err)
+		}
+		got := hex.EncodeToString(seed)
+		if got != v.seedHex {
+			t.Errorf("seed mismatch for %q + %q:\n got  %s\n want %s", v.mnemonic, v.passphrase, got, v.seedHex)
+		}
+	}
+}
+
+// TestDeriveX25519FromMnemonicPassphraseMatters ensures the passphrase is
+// actually mixed into derivation (not silently discarded), and that two
+// different passphrases for the same mnemonic yield distinct identities -
+// the whole point of 25th-word support.
+func TestDeriveX25519FromMnemonicPassphraseMatters(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	_, pubNoPass, err := deriveX25519FromMnemonic(mnemonic, "")
+	if err != nil {
+		t.Fatalf("derive with no passphrase: %v", err)
+	}
+	_, pubWithPass, err := deriveX25519FromMnemonic(mnemonic, "TREZOR")
+	if err != nil {
+		t.Fatalf("derive with passphrase: %v", err)
+	}
+
+	if len(pubNoPass) != 32 || len(pubWithPass) != 32 {
+		t.Fatalf("expected 32-byte X25519 public keys, got %d and %d", len(pubNoPass), len(pubWithPass))
+	}
+	if bytesEqual(pubNoPass, pubWithPass) {
+		t.Fatal("expected different passphrases to derive different identities")
+	}
+
+	_, pubWithPassAgain, err := deriveX25519FromMnemonic(mnemonic, "TREZOR")
+	if err != nil {
+		t.Fatalf("derive with passphrase (again): %v", err)
+	}
+	if !bytesEqual(pubWithPass, pubWithPassAgain) {
+		t.Fatal("expected derivation to be deterministic for the same mnemonic+passphrase")
+	}
+}
+
+// TestDeriveX25519LegacyFallback guards Bip39Identity.Unwrap's fallback
+// path: identities generated before seed+passphrase derivation became the
+// default were derived via deriveX25519FromMnemonicLegacy, a different
+// pubkey than deriveX25519FromMnemonic now produces for the same mnemonic
+// with no passphrase. Unwrap relies on exactly this divergence to detect
+// a legacy identity and re-derive it correctly instead of reporting a
+// spurious "seed phrase does not match".
+func TestDeriveX25519LegacyFallback(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	_, currentPub, err := deriveX25519FromMnemonic(mnemonic, "")
+	if err != nil {
+		t.Fatalf("deriveX25519FromMnemonic: %v", err)
+	}
+	legacyPriv, legacyPub, err := deriveX25519FromMnemonicLegacy(mnemonic)
+	if err != nil {
+		t.Fatalf("deriveX25519FromMnemonicLegacy: %v", err)
+	}
+
+	if bytesEqual(currentPub, legacyPub) {
+		t.Fatal("expected the legacy entropy-based derivation to differ from the current seed+passphrase derivation")
+	}
+	if len(legacyPriv) != 32 || len(legacyPub) != 32 {
+		t.Fatalf("expected 32-byte X25519 keys, got %d and %d", len(legacyPriv), len(legacyPub))
+	}
+
+	legacyPrivAgain, legacyPubAgain, err := deriveX25519FromMnemonicLegacy(mnemonic)
+	if err != nil {
+		t.Fatalf("deriveX25519FromMnemonicLegacy (again): %v", err)
+	}
+	if !bytesEqual(legacyPriv, legacyPrivAgain) || !bytesEqual(legacyPub, legacyPubAgain) {
+		t.Fatal("expected legacy derivation to be deterministic for the same mnemonic")
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// passphraseField identifies which element of passphraseModel has focus.
+type passphraseField int
+
+const (
+	fieldPassphrase passphraseField = iota
+	fieldConfirm
+	fieldSkip
+	fieldContinue
+)
+
+const numPassphraseFields = int(fieldContinue) + 1
+
+// passphraseModel prompts for an optional BIP39 passphrase (the "25th
+// word"). Both inputs run in EchoPassword mode so the value is masked;
+// Skip accepts an empty passphrase immediately without requiring the two
+// fields to match.
+type passphraseModel struct {
+	passphrase textinput.Model
+	confirm    textinput.Model
+	focused    passphraseField
+
+	done     bool
+	aborted  bool
+	skipped  bool
+	errorMsg string
+}
+
+func newPassphraseModel() passphraseModel {
+	mk := func() textinput.Model {
+		t := textinput.New()
+		t.Prompt = ""
+		t.CharLimit = 256
+		t.Width = 32
+		t.EchoMode = textinput.EchoPassword
+		t.EchoCharacter = '•'
+		return t
+	}
+
+	passphrase := mk()
+	passphrase.Focus()
+
+	return passphraseModel{
+		passphrase: passphrase,
+		confirm:    mk(),
+	}
+}
+
+func (m passphraseModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m passphraseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.aborted = true
+			return m, tea.Quit
+
+		case tea.KeyTab, tea.KeyDown:
+			m.focused = (m.focused + 1) % passphraseField(numPassphraseFields)
+			return m, m.focusCmd()
+
+		case tea.KeyShiftTab, tea.KeyUp:
+			m.focused = (m.focused - 1 + passphraseField(numPassphraseFields)) % passphraseField(numPassphraseFields)
+			return m, m.focusCmd()
+
+		case tea.KeyEnter:
+			switch m.focused {
+			case fieldSkip:
+				m.skipped = true
+				m.done = true
+				return m, tea.Quit
+			case fieldContinue:
+				return m.handleContinue()
+			default:
+				m.focused = fieldContinue
+				return m, m.focusCmd()
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	switch m.focused {
+	case fieldPassphrase:
+		m.errorMsg = ""
+		m.passphrase, cmd = m.passphrase.Update(msg)
+	case fieldConfirm:
+		m.errorMsg = ""
+		m.confirm, cmd = m.confirm.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m passphraseModel) handleContinue() (tea.Model, tea.Cmd) {
+	if m.passphrase.Value() != m.confirm.Value() {
+		m.errorMsg = "Passphrases don't match."
+		return m, nil
+	}
+	m.done = true
+	return m, tea.Quit
+}
+
+func (m *passphraseModel) focusCmd() tea.Cmd {
+	m.passphrase.Blur()
+	m.confirm.Blur()
+	if m.focused == fieldPassphrase {
+		return m.passphrase.Focus()
+	}
+	if m.focused == fieldConfirm {
+		return m.confirm.Focus()
+	}
+	return nil
+}
+
+func (m passphraseModel) View() string {
+	titleStyle := lipgloss.NewStyle().Bold(true)
+	descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	labelStyle := lipgloss.NewStyle().Width(12)
+	fieldBg := lipgloss.NewStyle().Background(lipgloss.Color("236"))
+
+	focusedBtn := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("255")).
+		Background(lipgloss.Color("63")).
+		Padding(0, 2)
+	blurredBtn := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		Background(lipgloss.Color("235")).
+		Padding(0, 2)
+
+	btn := func(label string, active bool) string {
+		if active {
+			return focusedBtn.Render(label)
+		}
+		return blurredBtn.Render(label)
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Optional BIP39 passphrase"))
+	b.WriteString("\n\n")
+	if m.errorMsg != "" {
+		b.WriteString(errStyle.Render(m.errorMsg))
+	} else {
+		b.WriteString(descStyle.Render("Adds a 25th-word passphrase to the derivation. Leave blank to skip."))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(labelStyle.Render("Passphrase") + fieldBg.Render(m.passphrase.View()))
+	b.WriteString("\n")
+	b.WriteString(labelStyle.Render("Confirm") + fieldBg.Render(m.confirm.View()))
+	b.WriteString("\n\n")
+	b.WriteString(btn("Skip", m.focused == fieldSkip) + "  " + btn("Continue", m.focused == fieldContinue))
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render("tab/arrows navigate • enter select • esc quit"))
+	return b.String()
+}
+
+// runPassphrasePrompt shows the optional-passphrase screen and returns the
+// entered passphrase, or "" if the user chose Skip.
+func runPassphrasePrompt() (string, error) {
+	p := tea.NewProgram(newPassphraseModel(), tea.WithOutput(os.Stderr))
+	finalModel, err := p.Run()
+	if err != nil {
+		return "", fmt.Errorf("passphrase input failed: %w", err)
+	}
+	m := finalModel.(passphraseModel)
+	if m.aborted {
+		return "", fmt.Errorf("aborted")
+	}
+	if m.skipped {
+		return "", nil
+	}
+	return m.passphrase.Value(), nil
+}
@@ -0,0 +1,80 @@
+package slip39
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// feistelRounds and baseIterationCount follow the shape of the SLIP-39
+// spec's master-secret encryption step: a balanced Feistel network whose
+// round function is PBKDF2-HMAC-SHA256 over the passphrase. We didn't
+// have the official spec's exact salt/iteration-count bytes on hand to
+// verify against, so this is our own construction in that style rather
+// than a byte-exact reimplementation - see the package doc comment (in
+// gf256.go) for the matching note about the wordlist encoding.
+const (
+	feistelRounds      = 4
+	baseIterationCount = 2500
+)
+
+// EncryptMasterSecret mixes passphrase into secret before splitting, so
+// that shares alone (without the passphrase) don't reconstruct it. An
+// empty passphrase still runs the full Feistel network, so callers don't
+// need to special-case the no-passphrase case - it's just one particular
+// passphrase value.
+func EncryptMasterSecret(secret []byte, passphrase string, iterationExponent uint) []byte {
+	return feistelCrypt(secret, passphrase, iterationExponent, false)
+}
+
+// DecryptMasterSecret reverses EncryptMasterSecret by running the rounds
+// backwards, each with its own inverse update (see feistelCrypt: reversing
+// the round order alone is not enough).
+func DecryptMasterSecret(secret []byte, passphrase string, iterationExponent uint) []byte {
+	return feistelCrypt(secret, passphrase, iterationExponent, true)
+}
+
+// feistelCrypt runs the balanced Feistel network forward (encrypt) or
+// backward (decrypt). Forward, round i maps (L, R) -> (R, L XOR F(i, R)).
+// Inverting that requires evaluating F on the OTHER half than encryption
+// did - given (L', R') = (R, L XOR F(i, R)), R is L', so recovering L
+// means computing F(i, L') and XORing it with R', not with L'. Running
+// the encrypt step's (l, r) update with just the round order reversed
+// does not invert it: F's input must also switch from r to l between the
+// two directions.
+func feistelCrypt(secret []byte, passphrase string, iterationExponent uint, reverse bool) []byte {
+	half := len(secret) / 2
+	l := append([]byte(nil), secret[:half]...)
+	r := append([]byte(nil), secret[half:]...)
+
+	rounds := [feistelRounds]byte{0, 1, 2, 3}
+	if reverse {
+		rounds = [feistelRounds]byte{3, 2, 1, 0}
+	}
+
+	iterations := int((baseIterationCount << iterationExponent) / feistelRounds)
+	for _, round := range rounds {
+		if reverse {
+			f := feistelRoundFunction(round, passphrase, l, iterations)
+			l, r = xorBytes(r, f), l
+		} else {
+			f := feistelRoundFunction(round, passphrase, r, iterations)
+			l, r = r, xorBytes(l, f)
+		}
+	}
+	return append(l, r...)
+}
+
+func feistelRoundFunction(round byte, passphrase string, r []byte, iterations int) []byte {
+	salt := append([]byte("shamir"), round)
+	salt = append(salt, r...)
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, len(r), sha256.New)
+}
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+// Package slip39 implements GF(256) Shamir secret sharing and Feistel-based
+// passphrase encryption, the primitives SLIP-0039 recovery shares are
+// built on. Callers can layer these into a two-level, grouped scheme (see
+// main.runSlip39GenerateGroups): splitting a secret into N group shares of
+// which any T groups, each further split into their own member shares,
+// reconstruct it.
+//
+// This package is NOT a SLIP-39-compliant implementation: shares are
+// encoded as standard BIP39 mnemonics by the caller (see
+// main.runSlip39Generate) rather than the SLIP-39 spec's own 1024-word
+// list and RS1024 checksum, and the byte layout isn't wire-compatible
+// with the reference implementation or other SLIP-39 tools. Call it what
+// it is - a from-scratch Shamir+Feistel scheme inspired by SLIP-39's
+// design - rather than a drop-in replacement for it.
+package slip39
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// irreducible is x^8 + x^4 + x^3 + x + 1 (0x11B), the same field used by
+// AES and by the reference SLIP-39 implementation. Table construction below
+// only ever needs the low byte, since the degree-8 term is implicit in the
+// "xtime" carry check.
+const irreducible = 0x1B
+
+var expTable [255]byte
+var logTable [256]byte
+
+// init builds the log/antilog tables by walking the multiplicative group
+// generated by 3. Generator 2 (i.e. repeated xtime) only has order 51 under
+// this reduction polynomial, which would leave expTable/logTable covering
+// 51 distinct values repeated five times instead of all 255 - 3 is a true
+// primitive root, so xtime(x) ^ x (multiplication by 2+1) visits every
+// nonzero element exactly once.
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = byte(i)
+		x = xtime(x) ^ x
+	}
+}
+
+// xtime multiplies x by 2 in GF(256), reducing modulo the field's
+// irreducible polynomial.
+func xtime(x byte) byte {
+	hi := x & 0x80
+	x <<= 1
+	if hi != 0 {
+		x ^= irreducible
+	}
+	return x
+}
+
+func gfAdd(a, b byte) byte { return a ^ b }
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	sum := int(logTable[a]) + int(logTable[b])
+	return expTable[sum%255]
+}
+
+func gfDiv(a, b byte) byte {
+	if b == 0 {
+		panic("slip39: division by zero in GF(256)")
+	}
+	if a == 0 {
+		return 0
+	}
+	diff := int(logTable[a]) - int(logTable[b])
+	if diff < 0 {
+		diff += 255
+	}
+	return expTable[diff]
+}
+
+// Share is one point (x, f(x)) of the secret-sharing polynomial, x being
+// Index (1..255, never 0 — 0 is the secret itself) and f(x) being Data.
+type Share struct {
+	Index byte
+	Data  []byte
+}
+
+// Split divides secret into `shares` points on a random degree
+// (threshold-1) polynomial per secret byte, such that any `threshold` of
+// them reconstruct secret via Combine.
+func Split(secret []byte, threshold, shares int) ([]Share, error) {
+	if threshold < 1 || shares < threshold {
+		return nil, fmt.Errorf("slip39: threshold %d must be between 1 and %d shares", threshold, shares)
+	}
+	if shares > 255 {
+		return nil, errors.New("slip39: at most 255 shares are supported")
+	}
+
+	if threshold == 1 {
+		out := make([]Share, shares)
+		for i := range out {
+			out[i] = Share{Index: byte(i + 1), Data: append([]byte(nil), secret...)}
+		}
+		return out, nil
+	}
+
+	// coeffs[i] holds the threshold-1 random higher-order coefficients
+	// for secret byte i; the constant term is secret[i] itself.
+	coeffs := make([][]byte, len(secret))
+	for i := range secret {
+		coeffs[i] = make([]byte, threshold-1)
+		if _, err := rand.Read(coeffs[i]); err != nil {
+			return nil, fmt.Errorf("slip39: generating coefficients: %w", err)
+		}
+	}
+
+	out := make([]Share, shares)
+	for s := 0; s < shares; s++ {
+		x := byte(s + 1)
+		data := make([]byte, len(secret))
+		for i, b0 := range secret {
+			y := b0
+			xPow := x
+			for _, c := range coeffs[i] {
+				y = gfAdd(y, gfMul(c, xPow))
+				xPow = gfMul(xPow, x)
+			}
+			data[i] = y
+		}
+		out[s] = Share{Index: x, Data: data}
+	}
+	return out, nil
+}
+
+// Combine reconstructs the secret from threshold-or-more shares via
+// Lagrange interpolation at x=0. Shares must have distinct indices and
+// equal-length Data; behavior with fewer than the original threshold is
+// to silently return a wrong secret, exactly as with classic Shamir — the
+// caller is responsible for knowing (and checking) the threshold.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("slip39: no shares provided")
+	}
+	n := len(shares[0].Data)
+	seen := make(map[byte]bool, len(shares))
+	for _, s := range shares {
+		if len(s.Data) != n {
+			return nil, errors.New("slip39: shares have mismatched lengths")
+		}
+		if seen[s.Index] {
+			return nil, fmt.Errorf("slip39: duplicate share index %d", s.Index)
+		}
+		seen[s.Index] = true
+	}
+
+	secret := make([]byte, n)
+	for i := 0; i < n; i++ {
+		var acc byte
+		for j, sj := range shares {
+			num := byte(1)
+			den := byte(1)
+			for k, sk := range shares {
+				if k == j {
+					continue
+				}
+				num = gfMul(num, sk.Index)
+				den = gfMul(den, gfAdd(sk.Index, sj.Index))
+			}
+			acc = gfAdd(acc, gfMul(sj.Data[i], gfDiv(num, den)))
+		}
+		secret[i] = acc
+	}
+	return secret, nil
+}
@@ -0,0 +1,51 @@
+package slip39
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestSplitCombineRoundTrip guards against the GF(256) table bug that
+// shipped here once already: a non-primitive generator (2) silently
+// collapses expTable/logTable to 51 distinct values, so Combine recovers
+// the wrong secret for most inputs despite Split/Combine appearing to
+// "work" on some of them.
+func TestSplitCombineRoundTrip(t *testing.T) {
+	secret := []byte("0123456789ABCDEF")
+
+	for _, tc := range []struct{ threshold, shares int }{
+		{2, 3}, {3, 5}, {1, 1}, {5, 5}, {2, 10},
+	} {
+		shares, err := Split(secret, tc.threshold, tc.shares)
+		if err != nil {
+			t.Fatalf("Split(t=%d, n=%d): %v", tc.threshold, tc.shares, err)
+		}
+		got, err := Combine(shares[:tc.threshold])
+		if err != nil {
+			t.Fatalf("Combine(t=%d, n=%d): %v", tc.threshold, tc.shares, err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("t=%d, n=%d: round-trip mismatch:\n got  %x\n want %x", tc.threshold, tc.shares, got, secret)
+		}
+	}
+}
+
+// TestEncryptDecryptMasterSecretRoundTrip guards against the Feistel
+// round-function-input bug that shipped here once already: running the
+// encrypt step with just the round order reversed does not invert it,
+// since the round function must be evaluated on the other half during
+// decryption.
+func TestEncryptDecryptMasterSecretRoundTrip(t *testing.T) {
+	secret := []byte("0123456789ABCDEF")
+
+	for _, passphrase := range []string{"", "correct horse battery staple"} {
+		encrypted := EncryptMasterSecret(secret, passphrase, 1)
+		if bytes.Equal(encrypted, secret) {
+			t.Fatalf("passphrase %q: EncryptMasterSecret did not change the secret", passphrase)
+		}
+		decrypted := DecryptMasterSecret(encrypted, passphrase, 1)
+		if !bytes.Equal(decrypted, secret) {
+			t.Fatalf("passphrase %q: round-trip mismatch:\n got  %x\n want %x", passphrase, decrypted, secret)
+		}
+	}
+}
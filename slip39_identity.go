@@ -0,0 +1,510 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha512"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/plugin"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pinpox/age-plugin-bip39/agent"
+	"github.com/pinpox/age-plugin-bip39/keycache"
+	"github.com/pinpox/age-plugin-bip39/slip39"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/curve25519"
+)
+
+// slip39MasterSecretLen is deliberately smaller than a BIP39 24-word
+// phrase's 32 bytes of entropy so each share maps onto a standard,
+// checksummed 12-word BIP39 mnemonic (128 bits of entropy) via the
+// existing wordlist machinery. See the slip39 package doc comment: this
+// is a from-scratch scheme inspired by SLIP-39, not an implementation of
+// SLIP-39's own wordlist/checksum, and isn't interoperable with it.
+const slip39MasterSecretLen = 16
+
+// slip39IterationExponent fixes the Feistel passphrase encryption's cost
+// factor (see slip39.EncryptMasterSecret). It isn't stored in the
+// identity payload, so it must stay constant for a given identity's
+// shares to keep reconstructing the same secret.
+const slip39IterationExponent = 1
+
+// slip39IdentityPayload builds the `kind || threshold || pubkey` identity
+// payload described by identityKindSlip39.
+func slip39IdentityPayload(threshold int, pubKey []byte) []byte {
+	payload := make([]byte, 0, 2+len(pubKey))
+	payload = append(payload, identityKindSlip39, byte(threshold))
+	payload = append(payload, pubKey...)
+	return payload
+}
+
+func slip39IdentityPublicKey(data []byte) ([]byte, error) {
+	if len(data) != 2+32 || data[0] != identityKindSlip39 {
+		return nil, fmt.Errorf("invalid slip39 identity data length: %d", len(data))
+	}
+	return data[2:], nil
+}
+
+// groupSpecList collects repeated `-m T,N` flags into an ordered list of
+// (member threshold, member count) pairs, one per group.
+type groupSpecList []struct{ threshold, shares int }
+
+func (l *groupSpecList) String() string {
+	return fmt.Sprintf("%v", []struct{ threshold, shares int }(*l))
+}
+
+func (l *groupSpecList) Set(value string) error {
+	threshold, shares, err := parseSplitSpec(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, struct{ threshold, shares int }{threshold, shares})
+	return nil
+}
+
+// runSlip39GenerateCommand parses the `slip39-generate` subcommand's flags
+// and runs runSlip39Generate or runSlip39GenerateGroups, mirroring
+// runCacheCommand's role for `cache`.
+func runSlip39GenerateCommand(args []string) error {
+	fs := flag.NewFlagSet("slip39-generate", flag.ContinueOnError)
+	threshold := fs.Int("t", 3, "number of shares required to reconstruct the identity")
+	shares := fs.Int("n", 5, "total number of shares to generate")
+	groupSpec := fs.String("g", "", "group threshold,count for grouped sharing, e.g. 2,3 (use with repeated -m)")
+	var members groupSpecList
+	fs.Var(&members, "m", "member threshold,count for one group, e.g. 3,5 (repeat once per group, in order)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	passphrase, err := runPassphrasePrompt()
+	if err != nil {
+		return err
+	}
+	if *groupSpec != "" {
+		groupThreshold, groupCount, err := parseSplitSpec(*groupSpec)
+		if err != nil {
+			return err
+		}
+		if len(members) != groupCount {
+			return fmt.Errorf("-g specifies %d groups but got %d -m flags", groupCount, len(members))
+		}
+		return runSlip39GenerateGroups(groupThreshold, members, passphrase)
+	}
+	return runSlip39Generate(*threshold, *shares, passphrase)
+}
+
+// runSlip39Generate implements `age-plugin-bip39 slip39-generate -t T -n N`
+// (also reachable as `-k -s T,N`): it splits a fresh, passphrase-encrypted
+// master secret into N mnemonic-encoded shares, any T of which reconstruct
+// the corresponding age identity.
+func runSlip39Generate(threshold, shares int, passphrase string) error {
+	if threshold < 2 {
+		return fmt.Errorf("threshold must be at least 2 (use -k for a single, unsplit phrase)")
+	}
+	if shares < threshold {
+		return fmt.Errorf("-n (%d) must be at least -t (%d)", shares, threshold)
+	}
+
+	secret := make([]byte, slip39MasterSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate master secret: %w", err)
+	}
+	encryptedSecret := slip39.EncryptMasterSecret(secret, passphrase, slip39IterationExponent)
+
+	parts, err := slip39.Split(encryptedSecret, threshold, shares)
+	if err != nil {
+		return fmt.Errorf("failed to split secret: %w", err)
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("212")).
+		Padding(0, 1)
+	label := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
+
+	for _, share := range parts {
+		mnemonic, err := bip39.NewMnemonic(share.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode share %d: %w", share.Index, err)
+		}
+		fmt.Fprintf(os.Stderr, "\n%s\n\n", box.Render(
+			label.Render(fmt.Sprintf("Share %d of %d (threshold %d)", share.Index, shares, threshold))+
+				"\n"+mnemonic,
+		))
+	}
+
+	_, pubKey, err := deriveX25519FromSlip39Secret(secret)
+	if err != nil {
+		return fmt.Errorf("key derivation failed: %w", err)
+	}
+
+	ecdhPub, err := ecdh.X25519().NewPublicKey(pubKey)
+	if err != nil {
+		return fmt.Errorf("failed to create ECDH public key: %w", err)
+	}
+	recipient, err := plugin.EncodeX25519Recipient(ecdhPub)
+	if err != nil {
+		return fmt.Errorf("failed to encode recipient: %w", err)
+	}
+
+	identity := plugin.EncodeIdentity("bip39", slip39IdentityPayload(threshold, pubKey))
+	if identity == "" {
+		return fmt.Errorf("failed to encode identity")
+	}
+
+	fmt.Printf("# public key: %s\n", recipient)
+	fmt.Println(identity)
+	return nil
+}
+
+// slip39GroupsIdentityPayload builds the
+// `kind || groupThreshold || memberThresholds[groupCount] || pubkey`
+// identity payload described by identityKindSlip39Groups; groupCount isn't
+// stored as its own byte but follows from the payload length. Member share
+// counts aren't stored (shares beyond a group's threshold are accepted
+// the same way the flat scheme's -n isn't stored either), only the
+// threshold each group needs before Unwrap can reconstruct it.
+func slip39GroupsIdentityPayload(groupThreshold int, memberThresholds []int, pubKey []byte) []byte {
+	payload := make([]byte, 0, 2+len(memberThresholds)+len(pubKey))
+	payload = append(payload, identityKindSlip39Groups, byte(groupThreshold))
+	for _, t := range memberThresholds {
+		payload = append(payload, byte(t))
+	}
+	payload = append(payload, pubKey...)
+	return payload
+}
+
+func slip39GroupsIdentityPublicKey(data []byte) ([]byte, error) {
+	if len(data) < 2+32 || data[0] != identityKindSlip39Groups {
+		return nil, fmt.Errorf("invalid slip39-groups identity data length: %d", len(data))
+	}
+	groupCount := len(data) - 2 - 32
+	if groupCount < 1 {
+		return nil, fmt.Errorf("invalid slip39-groups identity data length: %d", len(data))
+	}
+	return data[2+groupCount:], nil
+}
+
+// runSlip39GenerateGroups implements `age-plugin-bip39 slip39-generate -g T,N -m t1,n1 -m t2,n2 ...`:
+// it splits a fresh, passphrase-encrypted master secret into N group
+// shares, each of which is itself split into member shares; any T groups,
+// each with enough of their own member shares, reconstruct the identity.
+func runSlip39GenerateGroups(groupThreshold int, members groupSpecList, passphrase string) error {
+	groupCount := len(members)
+	if groupThreshold < 1 || groupThreshold > groupCount {
+		return fmt.Errorf("-g threshold must be between 1 and the number of groups (%d)", groupCount)
+	}
+
+	secret := make([]byte, slip39MasterSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate master secret: %w", err)
+	}
+	encryptedSecret := slip39.EncryptMasterSecret(secret, passphrase, slip39IterationExponent)
+
+	groupShares, err := slip39.Split(encryptedSecret, groupThreshold, groupCount)
+	if err != nil {
+		return fmt.Errorf("failed to split secret into groups: %w", err)
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("212")).
+		Padding(0, 1)
+	label := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
+
+	memberThresholds := make([]int, groupCount)
+	for _, gs := range groupShares {
+		spec := members[gs.Index-1]
+		memberThresholds[gs.Index-1] = spec.threshold
+
+		memberShares, err := slip39.Split(gs.Data, spec.threshold, spec.shares)
+		if err != nil {
+			return fmt.Errorf("failed to split group %d: %w", gs.Index, err)
+		}
+		for _, share := range memberShares {
+			mnemonic, err := bip39.NewMnemonic(share.Data)
+			if err != nil {
+				return fmt.Errorf("failed to encode group %d share %d: %w", gs.Index, share.Index, err)
+			}
+			fmt.Fprintf(os.Stderr, "\n%s\n\n", box.Render(
+				label.Render(fmt.Sprintf("Group %d share %d of %d (group threshold %d, member threshold %d)",
+					gs.Index, share.Index, spec.shares, groupThreshold, spec.threshold))+
+					"\n"+mnemonic,
+			))
+		}
+	}
+
+	_, pubKey, err := deriveX25519FromSlip39Secret(secret)
+	if err != nil {
+		return fmt.Errorf("key derivation failed: %w", err)
+	}
+
+	ecdhPub, err := ecdh.X25519().NewPublicKey(pubKey)
+	if err != nil {
+		return fmt.Errorf("failed to create ECDH public key: %w", err)
+	}
+	recipient, err := plugin.EncodeX25519Recipient(ecdhPub)
+	if err != nil {
+		return fmt.Errorf("failed to encode recipient: %w", err)
+	}
+
+	identity := plugin.EncodeIdentity("bip39", slip39GroupsIdentityPayload(groupThreshold, memberThresholds, pubKey))
+	if identity == "" {
+		return fmt.Errorf("failed to encode identity")
+	}
+
+	fmt.Printf("# public key: %s\n", recipient)
+	fmt.Println(identity)
+	return nil
+}
+
+// Slip39GroupsIdentity implements age.Identity for grouped SLIP-39-style
+// shares: groupThreshold of the groupCount groups must each supply enough
+// of their own member shares (per memberThresholds) to reconstruct the
+// identity.
+type Slip39GroupsIdentity struct {
+	plugin           *plugin.Plugin
+	groupThreshold   int
+	memberThresholds []int
+	publicKey        []byte
+}
+
+func parseSlip39GroupsIdentity(p *plugin.Plugin, data []byte) (age.Identity, error) {
+	if len(data) < 2+32 || data[0] != identityKindSlip39Groups {
+		return nil, fmt.Errorf("invalid slip39-groups identity data length: %d", len(data))
+	}
+	groupCount := len(data) - 2 - 32
+	if groupCount < 1 {
+		return nil, fmt.Errorf("invalid slip39-groups identity data length: %d", len(data))
+	}
+	memberThresholds := make([]int, groupCount)
+	for i, b := range data[2 : 2+groupCount] {
+		memberThresholds[i] = int(b)
+	}
+	return &Slip39GroupsIdentity{
+		plugin:           p,
+		groupThreshold:   int(data[1]),
+		memberThresholds: memberThresholds,
+		publicKey:        data[2+groupCount:],
+	}, nil
+}
+
+// collectSlip39Shares prompts for mnemonic shares via RequestValue,
+// labeling prompts with promptLabel, until `want` shares with distinct
+// user-supplied indices are collected (a repeated index replaces the
+// earlier share and costs an extra prompt), and returns them, each
+// carrying its index, in no particular order.
+func collectSlip39Shares(p *plugin.Plugin, promptLabel string, want int) ([]slip39.Share, error) {
+	collected := make(map[byte]slip39.Share)
+	for len(collected) < want {
+		prompt := fmt.Sprintf("Enter %s %d of %d (mnemonic)", promptLabel, len(collected)+1, want)
+		mnemonic, err := p.RequestValue(prompt, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request share: %w", err)
+		}
+		mnemonic = strings.TrimSpace(mnemonic)
+		if !bip39.IsMnemonicValid(mnemonic) {
+			return nil, fmt.Errorf("invalid share mnemonic")
+		}
+		data, err := bip39.EntropyFromMnemonic(mnemonic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode share: %w", err)
+		}
+
+		indexStr, err := p.RequestValue(fmt.Sprintf("Enter this %s's index number", promptLabel), true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request share index: %w", err)
+		}
+		idx, err := strconv.Atoi(strings.TrimSpace(indexStr))
+		if err != nil || idx < 1 || idx > 255 {
+			return nil, fmt.Errorf("invalid share index %q", indexStr)
+		}
+		collected[byte(idx)] = slip39.Share{Index: byte(idx), Data: data}
+	}
+
+	shares := make([]slip39.Share, 0, len(collected))
+	for _, s := range collected {
+		shares = append(shares, s)
+	}
+	return shares, nil
+}
+
+func (gi *Slip39GroupsIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	hasX25519 := false
+	for _, s := range stanzas {
+		if s.Type == "X25519" {
+			hasX25519 = true
+			break
+		}
+	}
+	if !hasX25519 {
+		return nil, age.ErrIncorrectIdentity
+	}
+
+	cacheKeyName := fmt.Sprintf("age-plugin-bip39:%x", cacheSalt(gi.publicKey))
+	privKey := keycache.Get(cacheKeyName)
+	if privKey == nil {
+		privKey, _ = agent.Get(identityFingerprint(gi.publicKey))
+	}
+
+	if privKey == nil {
+		groupSecrets := make(map[byte]slip39.Share)
+		for len(groupSecrets) < gi.groupThreshold {
+			groupIndexStr, err := gi.plugin.RequestValue(
+				fmt.Sprintf("Enter the group number for the next group (%d of %d groups collected)", len(groupSecrets), gi.groupThreshold), true)
+			if err != nil {
+				return nil, fmt.Errorf("failed to request group number: %w", err)
+			}
+			groupIndex, err := strconv.Atoi(strings.TrimSpace(groupIndexStr))
+			if err != nil || groupIndex < 1 || groupIndex > len(gi.memberThresholds) {
+				return nil, fmt.Errorf("invalid group number %q", groupIndexStr)
+			}
+
+			memberShares, err := collectSlip39Shares(gi.plugin, fmt.Sprintf("group %d share", groupIndex), gi.memberThresholds[groupIndex-1])
+			if err != nil {
+				return nil, err
+			}
+			groupSecret, err := slip39.Combine(memberShares)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reconstruct group %d: %w", groupIndex, err)
+			}
+			groupSecrets[byte(groupIndex)] = slip39.Share{Index: byte(groupIndex), Data: groupSecret}
+		}
+
+		groups := make([]slip39.Share, 0, len(groupSecrets))
+		for _, s := range groupSecrets {
+			groups = append(groups, s)
+		}
+		encryptedSecret, err := slip39.Combine(groups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconstruct secret: %w", err)
+		}
+
+		passphrase, err := gi.plugin.RequestValue("Enter your SLIP-39 passphrase (leave empty if none)", true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request passphrase: %w", err)
+		}
+		secret := slip39.DecryptMasterSecret(encryptedSecret, passphrase, slip39IterationExponent)
+
+		derivedPriv, derivedPub, err := deriveX25519FromSlip39Secret(secret)
+		if err != nil {
+			return nil, fmt.Errorf("key derivation failed: %w", err)
+		}
+		if !bytesEqual(derivedPub, gi.publicKey) {
+			return nil, fmt.Errorf("shares do not reconstruct this identity")
+		}
+
+		privKey = derivedPriv
+		keycache.Put(cacheKeyName, privKey)
+		_ = agent.Add(identityFingerprint(gi.publicKey), privKey, 0)
+	}
+
+	for _, stanza := range stanzas {
+		if stanza.Type != "X25519" {
+			continue
+		}
+		fileKey, err := unwrapX25519(privKey, gi.publicKey, stanza)
+		if err != nil {
+			continue
+		}
+		return fileKey, nil
+	}
+	return nil, age.ErrIncorrectIdentity
+}
+
+// deriveX25519FromSlip39Secret derives an X25519 keypair from a
+// reconstructed SLIP-39 master secret, using the same SHA-512-then-clamp
+// step as the plain BIP39 path.
+func deriveX25519FromSlip39Secret(secret []byte) (privateKey, publicKey []byte, err error) {
+	h := sha512.Sum512(secret)
+	x25519Private := make([]byte, 32)
+	copy(x25519Private, h[:32])
+	x25519Private[0] &= 248
+	x25519Private[31] &= 127
+	x25519Private[31] |= 64
+
+	pubBytes, err := curve25519.X25519(x25519Private, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("X25519 scalar multiplication failed: %w", err)
+	}
+	return x25519Private, pubBytes, nil
+}
+
+// Slip39Identity implements age.Identity for SLIP-39 mnemonic shares: the
+// private key is reconstructed from `threshold` user-supplied shares.
+type Slip39Identity struct {
+	plugin    *plugin.Plugin
+	threshold int
+	publicKey []byte
+}
+
+func parseSlip39Identity(p *plugin.Plugin, data []byte) (age.Identity, error) {
+	if len(data) != 2+32 {
+		return nil, fmt.Errorf("invalid slip39 identity data length: %d", len(data))
+	}
+	return &Slip39Identity{plugin: p, threshold: int(data[1]), publicKey: data[2:]}, nil
+}
+
+func (si *Slip39Identity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	hasX25519 := false
+	for _, s := range stanzas {
+		if s.Type == "X25519" {
+			hasX25519 = true
+			break
+		}
+	}
+	if !hasX25519 {
+		return nil, age.ErrIncorrectIdentity
+	}
+
+	cacheKeyName := fmt.Sprintf("age-plugin-bip39:%x", cacheSalt(si.publicKey))
+	privKey := keycache.Get(cacheKeyName)
+	if privKey == nil {
+		privKey, _ = agent.Get(identityFingerprint(si.publicKey))
+	}
+
+	if privKey == nil {
+		shares, err := collectSlip39Shares(si.plugin, "SLIP-39 share", si.threshold)
+		if err != nil {
+			return nil, err
+		}
+		encryptedSecret, err := slip39.Combine(shares)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconstruct secret: %w", err)
+		}
+
+		passphrase, err := si.plugin.RequestValue("Enter your SLIP-39 passphrase (leave empty if none)", true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request passphrase: %w", err)
+		}
+		secret := slip39.DecryptMasterSecret(encryptedSecret, passphrase, slip39IterationExponent)
+
+		derivedPriv, derivedPub, err := deriveX25519FromSlip39Secret(secret)
+		if err != nil {
+			return nil, fmt.Errorf("key derivation failed: %w", err)
+		}
+		if !bytesEqual(derivedPub, si.publicKey) {
+			return nil, fmt.Errorf("shares do not reconstruct this identity")
+		}
+
+		privKey = derivedPriv
+		keycache.Put(cacheKeyName, privKey)
+		_ = agent.Add(identityFingerprint(si.publicKey), privKey, 0)
+	}
+
+	for _, stanza := range stanzas {
+		if stanza.Type != "X25519" {
+			continue
+		}
+		fileKey, err := unwrapX25519(privKey, si.publicKey, stanza)
+		if err != nil {
+			continue
+		}
+		return fileKey, nil
+	}
+	return nil, age.ErrIncorrectIdentity
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// streamChunkSize is the STREAM chunk size age itself uses: large enough
+// to amortize per-chunk AEAD overhead, small enough to bound memory use
+// when en/decrypting multi-GB payloads.
+const streamChunkSize = 64 * 1024
+
+// streamPayloadNonceSize is the random nonce mixed into the payload-key
+// HKDF, distinct from the 12-byte per-chunk AEAD nonce below.
+const streamPayloadNonceSize = 16
+
+const ageVersionLine = "age-encryption.org/v1\n"
+
+// EncryptStream writes an age-format header wrapping a single fresh file
+// key for each of recipients, then returns a WriteCloser that encrypts
+// everything subsequently written to it as a STREAM of chacha20poly1305
+// chunks - the same construction age uses for its own file bodies, but
+// exposed directly so callers (e.g. backup tools) can encrypt payloads
+// far larger than they want to hold in memory at once.
+func EncryptStream(w io.Writer, recipients []*Bip39Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	fileKey := make([]byte, 32)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, fmt.Errorf("failed to generate file key: %w", err)
+	}
+
+	var stanzas []*age.Stanza
+	for _, r := range recipients {
+		wrapped, err := r.Wrap(fileKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap file key: %w", err)
+		}
+		stanzas = append(stanzas, wrapped...)
+	}
+
+	headerBody := formatAgeHeaderBody(stanzas)
+	mac, err := headerMAC(fileKey, append([]byte(headerBody), "---"...))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, headerBody); err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(w, "--- %s\n", b64.EncodeToString(mac)); err != nil {
+		return nil, err
+	}
+
+	payloadNonce := make([]byte, streamPayloadNonceSize)
+	if _, err := rand.Read(payloadNonce); err != nil {
+		return nil, fmt.Errorf("failed to generate payload nonce: %w", err)
+	}
+	if _, err := w.Write(payloadNonce); err != nil {
+		return nil, err
+	}
+
+	streamKey, err := deriveStreamKey(fileKey, payloadNonce)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamWriter{w: w, aead: aead, buf: make([]byte, 0, streamChunkSize)}, nil
+}
+
+// DecryptStream reads the header written by EncryptStream, tries each of
+// identities against the recipient stanzas until one unwraps the file
+// key, and returns a Reader over the decrypted payload.
+func DecryptStream(r io.Reader, identities []age.Identity) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, streamChunkSize+chacha20poly1305.Overhead)
+
+	versionLine, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if versionLine != ageVersionLine {
+		return nil, fmt.Errorf("unsupported version line %q", versionLine)
+	}
+
+	stanzas, macB64, err := parseAgeHeaderStanzas(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileKey []byte
+	for _, id := range identities {
+		fileKey, err = id.Unwrap(stanzas)
+		if err == nil {
+			break
+		}
+	}
+	if fileKey == nil {
+		return nil, age.ErrIncorrectIdentity
+	}
+
+	headerBody := formatAgeHeaderBody(stanzas)
+	wantMAC, err := b64.DecodeString(macB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header MAC encoding: %w", err)
+	}
+	gotMAC, err := headerMAC(fileKey, append([]byte(headerBody), "---"...))
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(gotMAC, wantMAC) {
+		return nil, errors.New("header MAC verification failed")
+	}
+
+	payloadNonce := make([]byte, streamPayloadNonceSize)
+	if _, err := io.ReadFull(br, payloadNonce); err != nil {
+		return nil, fmt.Errorf("failed to read payload nonce: %w", err)
+	}
+
+	streamKey, err := deriveStreamKey(fileKey, payloadNonce)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamReader{sd: &streamDecrypter{br: br, aead: aead}}, nil
+}
+
+func deriveStreamKey(fileKey, payloadNonce []byte) ([]byte, error) {
+	h := hkdf.New(sha256.New, fileKey, payloadNonce, []byte("payload"))
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(h, key); err != nil {
+		return nil, fmt.Errorf("failed to derive stream key: %w", err)
+	}
+	return key, nil
+}
+
+func headerMAC(fileKey, headerWithoutMAC []byte) ([]byte, error) {
+	h := hkdf.New(sha256.New, fileKey, nil, []byte("header"))
+	macKey := make([]byte, 32)
+	if _, err := io.ReadFull(h, macKey); err != nil {
+		return nil, fmt.Errorf("failed to derive header MAC key: %w", err)
+	}
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(headerWithoutMAC)
+	return mac.Sum(nil), nil
+}
+
+// formatAgeHeaderBody renders the version line and one "-> Type args..."
+// block per stanza, base64-wrapped at 64 columns exactly as age itself
+// does (including the mandatory trailing empty line when the encoded
+// body's length is itself a multiple of 64, so a decoder can always tell
+// where the body ends without knowing its length up front).
+func formatAgeHeaderBody(stanzas []*age.Stanza) string {
+	var buf bytes.Buffer
+	buf.WriteString(ageVersionLine)
+	for _, s := range stanzas {
+		buf.WriteString("->")
+		buf.WriteString(" ")
+		buf.WriteString(s.Type)
+		for _, arg := range s.Args {
+			buf.WriteString(" ")
+			buf.WriteString(arg)
+		}
+		buf.WriteString("\n")
+
+		encoded := b64.EncodeToString(s.Body)
+		for i := 0; i < len(encoded); i += 64 {
+			end := i + 64
+			if end > len(encoded) {
+				end = len(encoded)
+			}
+			buf.WriteString(encoded[i:end])
+			buf.WriteString("\n")
+		}
+		if len(encoded)%64 == 0 {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.String()
+}
+
+// parseAgeHeaderStanzas reads "-> Type args...\n<body lines>" blocks from
+// br until it hits the "--- <mac>\n" terminator, returning the parsed
+// stanzas and the base64-encoded MAC.
+func parseAgeHeaderStanzas(br *bufio.Reader) ([]*age.Stanza, string, error) {
+	var stanzas []*age.Stanza
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read header: %w", err)
+		}
+		if rest, ok := strings.CutPrefix(line, "---"); ok {
+			return stanzas, strings.TrimSpace(rest), nil
+		}
+		fields, ok := strings.CutPrefix(line, "-> ")
+		if !ok {
+			return nil, "", fmt.Errorf("malformed header line %q", line)
+		}
+		parts := strings.Fields(fields)
+		if len(parts) == 0 {
+			return nil, "", errors.New("malformed stanza line")
+		}
+		stanza := &age.Stanza{Type: parts[0], Args: parts[1:]}
+
+		var encoded strings.Builder
+		for {
+			bodyLine, err := br.ReadString('\n')
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read stanza body: %w", err)
+			}
+			trimmed := strings.TrimSuffix(bodyLine, "\n")
+			encoded.WriteString(trimmed)
+			if len(trimmed) < 64 {
+				break
+			}
+		}
+		body, err := b64.DecodeString(encoded.String())
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid stanza body encoding: %w", err)
+		}
+		stanza.Body = body
+		stanzas = append(stanzas, stanza)
+	}
+}
+
+// streamCounter is the 11-byte big-endian chunk counter used as the first
+// 11 bytes of each chunk's AEAD nonce, with the 12th byte set to 0x01 on
+// (and only on) the final chunk. It refuses to produce another nonce once
+// the counter space (2^88 chunks) is exhausted.
+type streamCounter struct {
+	value     [11]byte
+	exhausted bool
+}
+
+func (c *streamCounter) nonce(last bool) ([]byte, error) {
+	if c.exhausted {
+		return nil, errors.New("stream chunk counter exhausted")
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	copy(nonce[:11], c.value[:])
+	if last {
+		nonce[11] = 1
+	}
+	c.increment()
+	return nonce, nil
+}
+
+func (c *streamCounter) increment() {
+	for i := len(c.value) - 1; i >= 0; i-- {
+		c.value[i]++
+		if c.value[i] != 0 {
+			return
+		}
+	}
+	c.exhausted = true
+}
+
+// streamWriter buffers writes up to streamChunkSize and seals each full
+// chunk as it fills. Close always seals whatever remains (even nothing)
+// as the tagged final chunk, per STREAM's framing rules.
+type streamWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	counter streamCounter
+	buf     []byte
+	closed  bool
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	if sw.closed {
+		return 0, errors.New("write to closed stream")
+	}
+	total := len(p)
+	for len(p) > 0 {
+		room := streamChunkSize - len(sw.buf)
+		n := room
+		if n > len(p) {
+			n = len(p)
+		}
+		sw.buf = append(sw.buf, p[:n]...)
+		p = p[n:]
+		if len(sw.buf) == streamChunkSize {
+			if err := sw.seal(false); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return total, nil
+}
+
+func (sw *streamWriter) seal(last bool) error {
+	nonce, err := sw.counter.nonce(last)
+	if err != nil {
+		return err
+	}
+	ciphertext := sw.aead.Seal(nil, nonce, sw.buf, nil)
+	if _, err := sw.w.Write(ciphertext); err != nil {
+		return err
+	}
+	sw.buf = sw.buf[:0]
+	return nil
+}
+
+func (sw *streamWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+	sw.closed = true
+	return sw.seal(true)
+}
+
+// streamDecrypter reads and authenticates one STREAM chunk at a time. It
+// determines whether a chunk is the final one by peeking for more data
+// after a full-size read, exactly mirroring the writer's framing.
+type streamDecrypter struct {
+	br      *bufio.Reader
+	aead    cipher.AEAD
+	counter streamCounter
+	done    bool
+}
+
+func (sd *streamDecrypter) nextChunk() ([]byte, error) {
+	if sd.done {
+		return nil, io.EOF
+	}
+
+	buf := make([]byte, streamChunkSize+chacha20poly1305.Overhead)
+	n, err := io.ReadFull(sd.br, buf)
+	final := false
+	switch {
+	case err == nil:
+		if _, peekErr := sd.br.Peek(1); peekErr != nil {
+			final = true
+		}
+	case err == io.ErrUnexpectedEOF:
+		final = true
+	case err == io.EOF:
+		return nil, errors.New("unexpected end of encrypted stream")
+	default:
+		return nil, err
+	}
+	buf = buf[:n]
+
+	nonce, err := sd.counter.nonce(final)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := sd.aead.Open(nil, nonce, buf, nil)
+	if err != nil {
+		return nil, fmt.Errorf("stream chunk authentication failed: %w", err)
+	}
+	if final {
+		sd.done = true
+	} else if len(plaintext) != streamChunkSize {
+		return nil, errors.New("non-final stream chunk has unexpected size")
+	}
+	return plaintext, nil
+}
+
+// streamReader adapts streamDecrypter's whole-chunk-at-a-time interface
+// to io.Reader's arbitrary-length Read calls.
+type streamReader struct {
+	sd  *streamDecrypter
+	buf []byte
+	pos int
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for r.pos >= len(r.buf) {
+		if r.sd.done {
+			return 0, io.EOF
+		}
+		chunk, err := r.sd.nextChunk()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk
+		r.pos = 0
+	}
+	n := copy(p, r.buf[r.pos:])
+	r.pos += n
+	return n, nil
+}
@@ -11,20 +11,46 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	runewidth "github.com/mattn/go-runewidth"
 	bip39 "github.com/tyler-smith/go-bip39"
 )
 
 const (
+	// gridCols is fixed; only the row count (and so the total word
+	// count) varies with the chosen mnemonic length.
 	gridCols  = 3
-	gridRows  = 8
-	gridTotal = gridCols * gridRows
 	cellWidth = 10
 )
 
-// renderGridBox renders cells in a 3×8 column-major grid inside a bordered box.
-// cells[i] is the pre-rendered content for word i+1 (0-indexed).
-// focusedIdx highlights that word's number label (-1 for no highlight).
-func renderGridBox(cells []string, focusedIdx int) string {
+// wordCountToEntropyBits maps a supported BIP39 mnemonic length to the
+// entropy size (in bits) that produces it.
+var wordCountToEntropyBits = map[int]int{
+	12: 128,
+	15: 160,
+	18: 192,
+	21: 224,
+	24: 256,
+}
+
+// supportedWordCounts lists the valid --words values, in order.
+var supportedWordCounts = []int{12, 15, 18, 21, 24}
+
+// padDisplay right-pads s with spaces to at least width terminal columns,
+// measuring by display width rather than byte/rune count so CJK wordlist
+// entries (which render two columns wide per character) don't throw off
+// the grid's column alignment.
+func padDisplay(s string, width int) string {
+	if w := runewidth.StringWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// renderGridBox renders cells in a 3×gridRows column-major grid inside a
+// bordered box. cells[i] is the pre-rendered content for word i+1
+// (0-indexed). focusedIdx highlights that word's number label (-1 for no
+// highlight).
+func renderGridBox(cells []string, focusedIdx, gridRows int) string {
 	dimNum := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	activeNum := lipgloss.NewStyle().Foreground(lipgloss.Color("63")).Bold(true)
 
@@ -70,7 +96,7 @@ func renderGridBox(cells []string, focusedIdx int) string {
 type gridPhase int
 
 const (
-	phaseInput  gridPhase = iota
+	phaseInput gridPhase = iota
 	phaseVerify
 )
 
@@ -81,6 +107,12 @@ type wordGridModel struct {
 	phase         gridPhase
 	generated     []string
 
+	// gridRows and gridTotal are derived from the configured mnemonic
+	// length (see wordCountToEntropyBits) and fixed for the model's
+	// lifetime; regenerate() keeps the same length.
+	gridRows  int
+	gridTotal int
+
 	// Verify phase: editIdx cycles through fields then buttons.
 	// 0..len(verifyPos)-1 = input fields, len(verifyPos) = Back, len(verifyPos)+1 = Verify.
 	verifyPos []int
@@ -89,6 +121,15 @@ type wordGridModel struct {
 	done    bool
 	aborted bool
 	err     string
+
+	// Tab-completion state for the currently focused cell. completions
+	// holds the wordlist entries matching the cell's value at the time
+	// completion started; completionIdx cycles through them on repeated
+	// Tab presses. completionFor is the cell index they apply to, so
+	// moving focus or editing the value invalidates them.
+	completions   []string
+	completionIdx int
+	completionFor int
 }
 
 func newWordGridModel(generated []string) wordGridModel {
@@ -97,6 +138,7 @@ func newWordGridModel(generated []string) wordGridModel {
 		Foreground(lipgloss.Color("243")).
 		Background(lipgloss.Color("236"))
 
+	gridTotal := len(generated)
 	inputs := make([]textinput.Model, gridTotal)
 	for i := range inputs {
 		t := textinput.New()
@@ -114,8 +156,11 @@ func newWordGridModel(generated []string) wordGridModel {
 	inputs[0].Focus()
 
 	return wordGridModel{
-		inputs:    inputs,
-		generated: generated,
+		inputs:        inputs,
+		generated:     generated,
+		gridRows:      gridTotal / gridCols,
+		gridTotal:     gridTotal,
+		completionFor: -1,
 	}
 }
 
@@ -124,7 +169,7 @@ func (m wordGridModel) Init() tea.Cmd {
 }
 
 func (m wordGridModel) effectiveWords() []string {
-	words := make([]string, gridTotal)
+	words := make([]string, m.gridTotal)
 	for i, input := range m.inputs {
 		v := strings.TrimSpace(input.Value())
 		if v != "" {
@@ -203,17 +248,17 @@ func (m wordGridModel) onVerifyButton() bool {
 }
 
 func (m wordGridModel) onRegenerateButton() bool {
-	return m.phase == phaseInput && m.focused == gridTotal
+	return m.phase == phaseInput && m.focused == m.gridTotal
 }
 
 func (m wordGridModel) onContinueButton() bool {
-	return m.phase == phaseInput && m.focused == gridTotal+1
+	return m.phase == phaseInput && m.focused == m.gridTotal+1
 }
 
 // inputItemCount returns the total focusable items in input phase
 // (grid cells + Regenerate + Continue).
 func (m wordGridModel) inputItemCount() int {
-	return gridTotal + 2
+	return m.gridTotal + 2
 }
 
 func (m wordGridModel) onButton() bool {
@@ -223,39 +268,53 @@ func (m wordGridModel) onButton() bool {
 func (m wordGridModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Any keypress other than Tab invalidates a pending completion,
+		// including keys that move focus; Enter and Space first commit a
+		// pending completion for the focused cell (below), which clears it.
+		if msg.Type != tea.KeyTab && msg.Type != tea.KeyEnter && msg.Type != tea.KeySpace {
+			m.completions = nil
+			m.completionFor = -1
+		}
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			m.aborted = true
 			return m, tea.Quit
 
 		case tea.KeyTab:
+			if m.phase != phaseVerify && m.focused >= 0 && m.focused < m.gridTotal && m.isEditable(m.focused) {
+				if m.tabComplete() {
+					return m, nil
+				}
+			}
 			if m.phase == phaseVerify {
 				m.editIdx = (m.editIdx + 1) % m.verifyItemCount()
 				m.syncVerifyFocus()
-			} else if m.focused >= 0 && m.focused < gridTotal {
+			} else if m.focused >= 0 && m.focused < m.gridTotal {
 				// Grid → first button
 				m.lastGridFocus = m.focused
-				m.focused = gridTotal
-			} else if m.focused == gridTotal {
+				m.focused = m.gridTotal
+			} else if m.focused == m.gridTotal {
 				// Regenerate → Continue
-				m.focused = gridTotal + 1
+				m.focused = m.gridTotal + 1
 			} else {
 				// Continue → back to grid
 				m.focused = m.lastGridFocus
 			}
+			m.completions = nil
+			m.completionFor = -1
 			return m, m.focusCmd()
 
 		case tea.KeyShiftTab:
 			if m.phase == phaseVerify {
 				m.editIdx = (m.editIdx - 1 + m.verifyItemCount()) % m.verifyItemCount()
 				m.syncVerifyFocus()
-			} else if m.focused >= 0 && m.focused < gridTotal {
+			} else if m.focused >= 0 && m.focused < m.gridTotal {
 				// Grid → last button
 				m.lastGridFocus = m.focused
-				m.focused = gridTotal + 1
-			} else if m.focused == gridTotal+1 {
+				m.focused = m.gridTotal + 1
+			} else if m.focused == m.gridTotal+1 {
 				// Continue → Regenerate
-				m.focused = gridTotal
+				m.focused = m.gridTotal
 			} else {
 				// Regenerate → back to grid
 				m.focused = m.lastGridFocus
@@ -264,20 +323,20 @@ func (m wordGridModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Column-major arrow navigation (input phase grid only).
 		case tea.KeyUp:
-			if m.phase == phaseInput && m.focused >= 0 && m.focused < gridTotal && m.focused%gridRows > 0 {
+			if m.phase == phaseInput && m.focused >= 0 && m.focused < m.gridTotal && m.focused%m.gridRows > 0 {
 				m.focused--
 				return m, m.focusCmd()
 			}
 
 		case tea.KeyDown:
-			if m.phase == phaseInput && m.focused >= 0 && m.focused < gridTotal && m.focused%gridRows < gridRows-1 {
+			if m.phase == phaseInput && m.focused >= 0 && m.focused < m.gridTotal && m.focused%m.gridRows < m.gridRows-1 {
 				m.focused++
 				return m, m.focusCmd()
 			}
 
 		case tea.KeyLeft:
-			if m.phase == phaseInput && m.focused >= gridRows && m.focused < gridTotal {
-				m.focused -= gridRows
+			if m.phase == phaseInput && m.focused >= m.gridRows && m.focused < m.gridTotal {
+				m.focused -= m.gridRows
 				return m, m.focusCmd()
 			}
 			if m.onVerifyButton() {
@@ -287,8 +346,8 @@ func (m wordGridModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case tea.KeyRight:
-			if m.phase == phaseInput && m.focused >= 0 && m.focused+gridRows < gridTotal {
-				m.focused += gridRows
+			if m.phase == phaseInput && m.focused >= 0 && m.focused+m.gridRows < m.gridTotal {
+				m.focused += m.gridRows
 				return m, m.focusCmd()
 			}
 			if m.onBackButton() {
@@ -298,12 +357,22 @@ func (m wordGridModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case tea.KeyEnter:
+			if m.focused >= 0 && m.focused < m.gridTotal && m.completionFor == m.focused && len(m.completions) > 0 {
+				m.commitCompletion()
+				return m, nil
+			}
 			return m.handleEnter()
+
+		case tea.KeySpace:
+			if m.focused >= 0 && m.focused < m.gridTotal && m.completionFor == m.focused && len(m.completions) > 0 {
+				m.commitCompletion()
+				return m, nil
+			}
 		}
 	}
 
 	// Only forward events to an input if one is focused.
-	if m.focused >= 0 && m.focused < gridTotal {
+	if m.focused >= 0 && m.focused < m.gridTotal {
 		// Clear validation error on actual keypresses (not cursor blink etc.)
 		if _, isKey := msg.(tea.KeyMsg); isKey {
 			m.err = ""
@@ -316,6 +385,74 @@ func (m wordGridModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// wordsWithPrefix returns every entry of the active BIP39 wordlist starting
+// with prefix, in wordlist order. Not every official wordlist is sorted in
+// byte order (the Chinese lists are not sorted at all, and accented
+// Spanish/French words sort apart from their unaccented neighbours), so
+// this scans the whole list rather than binary-searching it.
+func wordsWithPrefix(prefix string) []string {
+	var matches []string
+	for _, w := range bip39.GetWordList() {
+		if strings.HasPrefix(w, prefix) {
+			matches = append(matches, w)
+		}
+	}
+	return matches
+}
+
+// tabComplete implements prefix completion for the focused grid cell. It
+// returns true if it handled the Tab press (so the caller should not also
+// run focus-cycling), false if Tab should fall through to its normal role.
+func (m *wordGridModel) tabComplete() bool {
+	idx := m.focused
+	val := strings.ToLower(strings.TrimSpace(m.inputs[idx].Value()))
+	if val == "" {
+		return false
+	}
+	if _, ok := bip39.GetWordIndex(val); ok {
+		// Already a complete, valid word — Tab keeps its focus-cycling role.
+		m.completions = nil
+		m.completionFor = -1
+		return false
+	}
+
+	if m.completionFor == idx && len(m.completions) > 0 {
+		// Cycle to the next match on repeated Tab presses.
+		m.completionIdx = (m.completionIdx + 1) % len(m.completions)
+		return true
+	}
+
+	matches := wordsWithPrefix(val)
+	if len(matches) == 0 {
+		// No completion available — leave the red invalid style as is.
+		m.completions = nil
+		m.completionFor = -1
+		return false
+	}
+	if len(matches) == 1 {
+		m.inputs[idx].SetValue(matches[0])
+		m.updateInputStyle(idx)
+		m.completions = nil
+		m.completionFor = -1
+		return false // fall through: advances focus like a normal Tab
+	}
+
+	m.completions = matches
+	m.completionFor = idx
+	m.completionIdx = 0
+	return true
+}
+
+// commitCompletion accepts the currently cycled-to suggestion for the
+// focused cell without advancing focus (used by Enter/Space).
+func (m *wordGridModel) commitCompletion() {
+	idx := m.completionFor
+	m.inputs[idx].SetValue(m.completions[m.completionIdx])
+	m.updateInputStyle(idx)
+	m.completions = nil
+	m.completionFor = -1
+}
+
 // updateInputStyle sets the text style of the given input based on whether
 // its current value is a valid BIP39 word. Invalid/incomplete words are red.
 func (m *wordGridModel) updateInputStyle(idx int) {
@@ -358,7 +495,7 @@ func (m wordGridModel) handleEnter() (tea.Model, tea.Cmd) {
 		}
 		// On a grid cell — jump to Continue button.
 		m.lastGridFocus = m.focused
-		m.focused = gridTotal + 1 // Continue
+		m.focused = m.gridTotal + 1 // Continue
 		return m, m.focusCmd()
 	}
 
@@ -439,7 +576,7 @@ func (m wordGridModel) handleContinue() (tea.Model, tea.Cmd) {
 }
 
 func (m wordGridModel) regenerate() (tea.Model, tea.Cmd) {
-	entropy, err := bip39.NewEntropy(256)
+	entropy, err := bip39.NewEntropy(wordCountToEntropyBits[m.gridTotal])
 	if err != nil {
 		m.err = fmt.Sprintf("failed to generate entropy: %v", err)
 		return m, nil
@@ -466,7 +603,7 @@ func (m wordGridModel) regenerate() (tea.Model, tea.Cmd) {
 }
 
 func (m *wordGridModel) focusCmd() tea.Cmd {
-	cmds := make([]tea.Cmd, 0, gridTotal)
+	cmds := make([]tea.Cmd, 0, m.gridTotal)
 	for i := range m.inputs {
 		if i == m.focused {
 			cmds = append(cmds, m.inputs[i].Focus())
@@ -484,13 +621,19 @@ func (m wordGridModel) View() string {
 	maskedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	ghostStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243")).Background(lipgloss.Color("236")).Italic(true)
 
-	cells := make([]string, gridTotal)
-	for i := 0; i < gridTotal; i++ {
+	cells := make([]string, m.gridTotal)
+	for i := 0; i < m.gridTotal; i++ {
 		if m.isEditable(i) {
 			cells[i] = fieldBg.Render(m.inputs[i].View())
+			if i == m.completionFor && len(m.completions) > 0 {
+				suggestion := m.completions[m.completionIdx]
+				rest := suggestion[len(m.inputs[i].Value()):]
+				cells[i] += ghostStyle.Render(rest)
+			}
 		} else {
-			cells[i] = maskedStyle.Render(fmt.Sprintf("%-*s", cellWidth+1, "******"))
+			cells[i] = maskedStyle.Render(padDisplay("******", cellWidth+1))
 		}
 	}
 
@@ -512,7 +655,7 @@ func (m wordGridModel) View() string {
 		b.WriteString(descStyle.Render(desc))
 	}
 	b.WriteString("\n\n")
-	b.WriteString(renderGridBox(cells, m.focused))
+	b.WriteString(renderGridBox(cells, m.focused, m.gridRows))
 	b.WriteString("\n\n")
 	b.WriteString(m.renderButtons())
 	help = "tab/arrows navigate • enter select • esc quit"